@@ -0,0 +1,307 @@
+package gindump
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FileStore a Recorder which writes each dumped exchange to its own file
+// under the root directory organized by date and route:
+//
+//	{root}/{yyyy}/{MM}/{dd}/{METHOD}_{route}/{id}{ext}
+//
+// For example: "dumps/2026/10/18/GET_users_id/8d3a0a5bd9a44e6b1f2c3e4d5a6b7c8d.http".
+// The route is the matched gin route path, "NoRoute" if no route is matched,
+// or "outbound_{host}" for the outbound request dumped by the Transport.
+// The file extension is ".http" (TextFormatter), ".json" (JSONFormatter),
+// ".sh" (CurlFormatter), ".go" (GoFormatter) or ".txt".
+// The old files are deleted by the retention (max files and max age) periodically.
+type FileStore struct {
+	root      string
+	formatter Formatter
+	maxFiles  int
+	maxAge    time.Duration
+	onError   func(err error)
+
+	// cleanInterval the minimum interval of the retention cleaning
+	cleanInterval time.Duration
+
+	// lastClean the unix nano time of the last retention cleaning
+	lastClean int64
+
+	// mutex serialize the retention cleaning
+	mutex sync.Mutex
+}
+
+// NewFileStore create a file store which writes the dumped exchanges under the root directory
+// formatter: TextFormatter
+// maxFiles: 0 (unlimited)
+// maxAge: 0 (unlimited)
+func NewFileStore(root string) *FileStore {
+	return &FileStore{
+		root:          root,
+		formatter:     TextFormatter,
+		cleanInterval: time.Minute,
+	}
+}
+
+// SetFormatter set the file formatter.
+// Default: TextFormatter
+func (fst *FileStore) SetFormatter(f Formatter) {
+	fst.formatter = f
+}
+
+// SetMaxFiles set the maximum count of the dump files to keep, the oldest files are deleted.
+// Default: 0 (unlimited)
+func (fst *FileStore) SetMaxFiles(n int) {
+	fst.maxFiles = n
+}
+
+// SetMaxAge set the maximum age of the dump files to keep, the older files are deleted.
+// Default: 0 (unlimited)
+func (fst *FileStore) SetMaxAge(d time.Duration) {
+	fst.maxAge = d
+}
+
+// SetErrorHandler set the handler of the file write error and the periodic retention cleaning error.
+// Default: nil (the errors are ignored)
+func (fst *FileStore) SetErrorHandler(eh func(err error)) {
+	fst.onError = eh
+}
+
+// Record implements the Recorder interface.
+// The write error is reported to the error handler (see SetErrorHandler).
+func (fst *FileStore) Record(rec *Record) {
+	bb := &bytes.Buffer{}
+	fst.formatter.WriteRequest(bb, rec)  //nolint: errcheck
+	fst.formatter.WriteResponse(bb, rec) //nolint: errcheck
+
+	fp := filepath.Join(fst.root, fst.Path(rec))
+	err := os.MkdirAll(filepath.Dir(fp), 0750)
+	if err == nil {
+		err = os.WriteFile(fp, bb.Bytes(), 0600)
+	}
+	if err != nil {
+		fst.reportError(err)
+	}
+
+	if fst.maxFiles > 0 || fst.maxAge > 0 {
+		now := time.Now().UnixNano()
+		last := atomic.LoadInt64(&fst.lastClean)
+		if now-last >= int64(fst.cleanInterval) && atomic.CompareAndSwapInt64(&fst.lastClean, last, now) {
+			go func() {
+				if err := fst.Clean(); err != nil {
+					fst.reportError(err)
+				}
+			}()
+		}
+	}
+}
+
+// reportError report the error to the error handler
+func (fst *FileStore) reportError(err error) {
+	if fst.onError != nil {
+		fst.onError(err)
+	}
+}
+
+// Path returns the relative file path of the record
+func (fst *FileStore) Path(rec *Record) string {
+	return filepath.Join(
+		rec.Start.Format("2006"),
+		rec.Start.Format("01"),
+		rec.Start.Format("02"),
+		sanitizeName(rec.Method)+"_"+routeBucket(rec),
+		rec.ID+formatterExt(fst.formatter),
+	)
+}
+
+// routeBucket returns the route directory name of the record.
+// The unmatched inbound requests share the "NoRoute" bucket and the outbound requests
+// share the "outbound_{host}" bucket, so the arbitrary URL paths do not create the unbounded directories.
+func routeBucket(rec *Record) string {
+	if rec.Route != "" {
+		return routeName(rec.Route)
+	}
+	if rec.ParentID != "" || strings.Contains(rec.URL, "://") {
+		host := sanitizeName(rec.Host)
+		if len(host) > 100 {
+			host = host[:100]
+		}
+		if host == "" {
+			return "outbound"
+		}
+		return "outbound_" + host
+	}
+	return "NoRoute"
+}
+
+// Clean delete the dump files exceeded the retention (max files and max age) and the empty date directories.
+// Only the files of the layout written by the store ("{yyyy}/{MM}/{dd}/{route}/{id}{ext}") are deleted,
+// the other files and directories under the root are left untouched.
+// It's called periodically by Record().
+func (fst *FileStore) Clean() error {
+	fst.mutex.Lock()
+	defer fst.mutex.Unlock()
+
+	type file struct {
+		path    string
+		modTime time.Time
+	}
+
+	files := []*file{}
+	dirs := []string{}
+
+	years, err := readDirs(fst.root, isDigits(4))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, y := range years {
+		months, _ := readDirs(y, isDigits(2))
+		for _, m := range months {
+			days, _ := readDirs(m, isDigits(2))
+			for _, d := range days {
+				routes, _ := readDirs(d, func(name string) bool { return sanitizeName(name) == name })
+				for _, r := range routes {
+					des, _ := os.ReadDir(r)
+					for _, de := range des {
+						if de.IsDir() || !isDumpFile(de.Name()) {
+							continue
+						}
+						fi, err := de.Info()
+						if err != nil {
+							continue
+						}
+						files = append(files, &file{filepath.Join(r, de.Name()), fi.ModTime()})
+					}
+					dirs = append(dirs, r)
+				}
+				dirs = append(dirs, d)
+			}
+			dirs = append(dirs, m)
+		}
+		dirs = append(dirs, y)
+	}
+
+	// newest first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	expired := time.Now().Add(-fst.maxAge)
+	for i, f := range files {
+		if (fst.maxFiles > 0 && i >= fst.maxFiles) || (fst.maxAge > 0 && f.modTime.Before(expired)) {
+			if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	// remove the empty date directories (deepest first), the non-empty directory is not removed
+	for _, d := range dirs {
+		os.Remove(d) //nolint: errcheck
+	}
+	return nil
+}
+
+// readDirs returns the paths of the sub directories of dir which name matches
+func readDirs(dir string, match func(name string) bool) ([]string, error) {
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := []string{}
+	for _, de := range des {
+		if de.IsDir() && match(de.Name()) {
+			ds = append(ds, filepath.Join(dir, de.Name()))
+		}
+	}
+	return ds, nil
+}
+
+// isDigits returns a function which reports whether the name is n digits
+func isDigits(n int) func(name string) bool {
+	return func(name string) bool {
+		if len(name) != n {
+			return false
+		}
+		for _, c := range name {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// isDumpFile reports whether the file name is "{hex id}{ext}" written by the store
+func isDumpFile(name string) bool {
+	ext := filepath.Ext(name)
+	switch ext {
+	case ".http", ".json", ".sh", ".go", ".txt":
+	default:
+		return false
+	}
+
+	id := strings.TrimSuffix(name, ext)
+	if id == "" || len(id) > 32 {
+		return false
+	}
+	for _, c := range id {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatterExt(f Formatter) string {
+	switch f {
+	case TextFormatter:
+		return ".http"
+	case JSONFormatter:
+		return ".json"
+	case CurlFormatter:
+		return ".sh"
+	case GoFormatter:
+		return ".go"
+	default:
+		return ".txt"
+	}
+}
+
+// routeName convert the route path to a directory name: "/users/:id" -> "users_id"
+func routeName(route string) string {
+	name := sanitizeName(route)
+	if name == "" {
+		return "root"
+	}
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return name
+}
+
+// sanitizeName replace the characters except [0-9A-Za-z.-] to "_",
+// the continuous "_" are merged and the leading/trailing "_" are trimmed.
+func sanitizeName(s string) string {
+	sb := &strings.Builder{}
+	for _, c := range s {
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '-' || (c == '.' && sb.Len() > 0) {
+			sb.WriteRune(c)
+		} else if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "_") {
+			sb.WriteByte('_')
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "_")
+}
@@ -0,0 +1,200 @@
+package gindump
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRouteName(t *testing.T) {
+	cs := map[string]string{
+		"":                "root",
+		"/":               "root",
+		"/users/:id":      "users_id",
+		"/static/*path":   "static_path",
+		"/a//b.json":      "a_b.json",
+		"/../../etc":      "etc",
+		"/v1/a b/..":      "v1_a_b_..",
+		"/中文/x":           "x",
+		"/users/:id/edit": "users_id_edit",
+	}
+
+	for s, w := range cs {
+		if a := routeName(s); a != w {
+			t.Errorf("routeName(%q) = %q, want %q", s, a, w)
+		}
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	root := t.TempDir()
+
+	fst := NewFileStore(root)
+	dumper := New(nil)
+	dumper.SetRecorder(fst)
+
+	router := gin.New()
+	router.Use(dumper.Handler())
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "user "+c.Param("id"))
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+
+	ymd := time.Now().Format("2006/01/02")
+	files, _ := filepath.Glob(filepath.Join(root, ymd, "GET_users_id", "*.http"))
+	if len(files) != 1 {
+		t.Fatalf("files = %v, want 1 file", files)
+	}
+
+	data, _ := os.ReadFile(files[0])
+	assertContains(t, "GET /users/1", string(data), "GET /users/1 HTTP/1.1", "HTTP/1.1 200 OK", "user 1")
+
+	files, _ = filepath.Glob(filepath.Join(root, ymd, "GET_NoRoute", "*.http"))
+	if len(files) != 1 {
+		t.Fatalf("files = %v, want 1 file", files)
+	}
+}
+
+func TestFileStorePath(t *testing.T) {
+	fst := NewFileStore("dumps")
+
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)
+	cs := []struct {
+		rec *Record
+		w   string
+	}{
+		{&Record{ID: "1", Start: start, Method: "GET", Route: "/users/:id", URL: "/users/1"}, "2026/10/18/GET_users_id/1.http"},
+		{&Record{ID: "2", Start: start, Method: "GET", URL: "/random/path/123"}, "2026/10/18/GET_NoRoute/2.http"},
+		{&Record{ID: "3", Start: start, Method: "POST", ParentID: "1", Host: "api.example.com:8443", URL: "https://api.example.com:8443/a"}, "2026/10/18/POST_outbound_api.example.com_8443/3.http"},
+		{&Record{ID: "4", Start: start, Method: "GET", Host: "example.com", URL: "http://example.com/b"}, "2026/10/18/GET_outbound_example.com/4.http"},
+	}
+
+	for i, c := range cs {
+		if a := fst.Path(c.rec); a != filepath.FromSlash(c.w) {
+			t.Errorf("[%d] Path() = %q, want %q", i, a, c.w)
+		}
+	}
+}
+
+func TestFileStoreError(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(root, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []error
+	fst := NewFileStore(root)
+	fst.SetErrorHandler(func(err error) {
+		errs = append(errs, err)
+	})
+
+	fst.Record(&Record{ID: newID(), Start: time.Now(), Method: "GET", URL: "/a"})
+	if len(errs) != 1 {
+		t.Errorf("errors = %v, want 1 error", errs)
+	}
+}
+
+func TestFileStoreClean(t *testing.T) {
+	root := t.TempDir()
+
+	fst := NewFileStore(root)
+	fst.SetFormatter(JSONFormatter)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		rec := &Record{ID: newID(), Seq: uint64(i), Start: start.AddDate(0, 0, -i), Method: "POST", Route: "/a/b", URL: "/a/b?c"}
+		fst.Record(rec)
+
+		// set the modification time to the record start time
+		fp := filepath.Join(root, fst.Path(rec))
+		if !strings.HasSuffix(fp, ".json") || !strings.Contains(fp, "POST_a_b") {
+			t.Errorf("Path() = %q", fp)
+		}
+		os.Chtimes(fp, rec.Start, rec.Start)
+	}
+
+	count := func() int {
+		n := 0
+		filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+			if err == nil && !fi.IsDir() {
+				n++
+			}
+			return nil
+		})
+		return n
+	}
+
+	if n := count(); n != 5 {
+		t.Fatalf("count() = %d, want %d", n, 5)
+	}
+
+	fst.SetMaxAge(time.Hour * 24 * 3)
+	if err := fst.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 3 {
+		t.Errorf("count() = %d, want %d", n, 3)
+	}
+
+	fst.SetMaxFiles(1)
+	if err := fst.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("count() = %d, want %d", n, 1)
+	}
+
+	// empty directories are removed
+	ds, _ := os.ReadDir(filepath.Join(root, start.Format("2006")))
+	if len(ds) == 0 || len(ds) > 2 {
+		t.Errorf("year directory entries = %d", len(ds))
+	}
+}
+
+func TestFileStoreCleanForeign(t *testing.T) {
+	root := t.TempDir()
+
+	fst := NewFileStore(root)
+	rec := &Record{ID: newID(), Start: time.Now().AddDate(0, 0, -10), Method: "GET", URL: "/a"}
+	fst.Record(rec)
+
+	dp := filepath.Join(root, fst.Path(rec))
+	foreign := []string{
+		filepath.Join(root, "important.txt"),
+		filepath.Join(root, "sub", "config.yml"),
+		filepath.Join(root, "2020", "notes.txt"),
+		filepath.Join(filepath.Dir(dp), "keep.http"),
+	}
+	for _, fp := range foreign {
+		os.MkdirAll(filepath.Dir(fp), 0750)
+		if err := os.WriteFile(fp, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(fp, rec.Start, rec.Start)
+	}
+	os.Chtimes(dp, rec.Start, rec.Start)
+	os.MkdirAll(filepath.Join(root, "empty"), 0750)
+
+	fst.SetMaxFiles(1)
+	fst.SetMaxAge(time.Hour)
+	if err := fst.Clean(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(dp); !os.IsNotExist(err) {
+		t.Errorf("dump file %q is not deleted", dp)
+	}
+	for _, fp := range append(foreign, filepath.Join(root, "empty")) {
+		if _, err := os.Stat(fp); err != nil {
+			t.Errorf("foreign file %q is deleted: %v", fp, err)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package gindump
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatter format the dump record
+type Formatter interface {
+	// WriteRequest write the request part of the record.
+	// If the dumper is not atomic, it is called before the request is processed,
+	// so the response part of the record is not available.
+	WriteRequest(w io.Writer, rec *Record) error
+
+	// WriteResponse write the response part of the record.
+	WriteResponse(w io.Writer, rec *Record) error
+}
+
+// TextFormatter the raw http text formatter (default)
+//
+//	>>>>>>>> {start} #{seq} {id} [parent={parent id}] >>>>>>>>
+//	{raw http request}
+//	<<<<<<<< {end} #{seq} {id} {duration} <<<<<<<<
+//	{raw http response}
+var TextFormatter Formatter = textFormatter{}
+
+// JSONFormatter the JSON lines formatter.
+// Each exchange is written as a single line JSON object:
+//
+//	{"id": "...", "parentId": "...", "seq": 1, "start": "...", "end": "...", "duration": 1.2,
+//	 "request": {"method": "GET", "url": "/", "proto": "HTTP/1.1", "host": "...", "remoteAddr": "...",
+//	   "header": {...}, "body": "...", "bodyEncoding": "utf-8", "bodyTruncated": 0,
+//	   "parts": [{"name": "...", "filename": "...", "contentType": "...", "size": 0,
+//	     "value": "...", "valueEncoding": "utf-8", "valueTruncated": 0}]},
+//	 "response": {"status": 200, "header": {...}, "trailer": {...}, "body": "...",
+//	   "bodyEncoding": "base64", "bodyTruncated": 0, "hijacked": false, "error": "..."}}
+//
+// The "duration" is in milliseconds.
+// The "bodyEncoding" is "utf-8" if the body is a valid UTF-8 text, otherwise "base64".
+// The "parts" are the summarized parts of the multipart/form-data request body,
+// the "body" of the multipart request is the text summary of the parts.
+var JSONFormatter Formatter = jsonFormatter{}
+
+// CurlFormatter the curl command line formatter.
+// Each request is written as a curl command line (the response is not written):
+//
+//	# {start} #{seq} {id}
+//	curl -X 'POST' 'http://example.com/path' \
+//	  -H 'Content-Type: application/json' \
+//	  --data-binary '{"a":1}'
+//
+// The redacted values are kept as "******" in the command line.
+var CurlFormatter Formatter = curlFormatter{}
+
+// GoFormatter the Go code formatter.
+// Each request is written as a httptest.NewRequest() Go code snippet (the response is not written):
+//
+//	// {start} #{seq} {id}
+//	req := httptest.NewRequest("POST", "http://example.com/path", strings.NewReader("{\"a\":1}"))
+//	req.Header.Add("Content-Type", "application/json")
+//
+// The redacted values are kept as "******" in the code.
+var GoFormatter Formatter = goFormatter{}
+
+const eol = "\r\n"
+
+//-------------------------------------------------
+
+type textFormatter struct{}
+
+func (textFormatter) WriteRequest(w io.Writer, rec *Record) error {
+	bb := &bytes.Buffer{}
+
+	if rec.ParentID == "" {
+		bb.WriteString(fmt.Sprintf(">>>>>>>> %s #%d %s >>>>>>>>", rec.Start.Format(defaultTimeFormat), rec.Seq, rec.ID))
+	} else {
+		bb.WriteString(fmt.Sprintf(">>>>>>>> %s #%d %s parent=%s >>>>>>>>", rec.Start.Format(defaultTimeFormat), rec.Seq, rec.ID, rec.ParentID))
+	}
+	bb.WriteString(eol)
+
+	bb.WriteString(fmt.Sprintf("%s %s %s", rec.Method, rec.URL, rec.Proto))
+	bb.WriteString(eol)
+	if rec.Host != "" {
+		bb.WriteString("Host: " + rec.Host)
+		bb.WriteString(eol)
+	}
+	rec.RequestHeader.WriteSubset(bb, map[string]bool{"Host": true}) //nolint: errcheck
+	bb.WriteString(eol)
+	bb.Write(rec.RequestBody)
+	writeTruncated(bb, rec.RequestTruncated)
+	bb.WriteString(eol)
+	bb.WriteString(eol)
+
+	_, err := w.Write(bb.Bytes())
+	return err
+}
+
+func (textFormatter) WriteResponse(w io.Writer, rec *Record) error {
+	bb := &bytes.Buffer{}
+
+	bb.WriteString(fmt.Sprintf("<<<<<<<< %s #%d %s %s <<<<<<<<", rec.End.Format(defaultTimeFormat), rec.Seq, rec.ID, rec.Duration()))
+	bb.WriteString(eol)
+
+	if rec.Error != "" {
+		bb.WriteString("[error: " + rec.Error + "]")
+	} else if rec.Hijacked {
+		if rec.Websocket {
+			bb.WriteString("[connection hijacked: websocket upgrade]")
+		} else {
+			bb.WriteString("[connection hijacked]")
+		}
+	} else {
+		bb.WriteString(fmt.Sprintf("%s %d %s", rec.Proto, rec.Status, http.StatusText(rec.Status)))
+		bb.WriteString(eol)
+		rec.ResponseHeader.Write(bb) //nolint: errcheck
+		bb.WriteString(eol)
+		bb.Write(rec.ResponseBody)
+		writeTruncated(bb, rec.ResponseTruncated)
+
+		if len(rec.ResponseTrailer) > 0 {
+			bb.WriteString(eol)
+			bb.WriteString(eol)
+			rec.ResponseTrailer.Write(bb) //nolint: errcheck
+		}
+	}
+	bb.WriteString(eol)
+	bb.WriteString(eol)
+
+	_, err := w.Write(bb.Bytes())
+	return err
+}
+
+func writeTruncated(bb *bytes.Buffer, n int) {
+	if n > 0 {
+		bb.WriteString(fmt.Sprintf("%s... (%d bytes truncated)", eol, n))
+	}
+}
+
+//-------------------------------------------------
+
+type curlFormatter struct{}
+
+func (curlFormatter) WriteRequest(w io.Writer, rec *Record) error {
+	s := fmt.Sprintf("# %s #%d %s\n%s\n\n", rec.Start.Format(defaultTimeFormat), rec.Seq, rec.ID, curlCommand(rec))
+	_, err := w.Write([]byte(s))
+	return err
+}
+
+// WriteResponse do nothing, only the request is written.
+func (curlFormatter) WriteResponse(w io.Writer, rec *Record) error {
+	return nil
+}
+
+//-------------------------------------------------
+
+type goFormatter struct{}
+
+func (goFormatter) WriteRequest(w io.Writer, rec *Record) error {
+	s := fmt.Sprintf("// %s #%d %s\n%s\n", rec.Start.Format(defaultTimeFormat), rec.Seq, rec.ID, goSnippet(rec))
+	_, err := w.Write([]byte(s))
+	return err
+}
+
+// WriteResponse do nothing, only the request is written.
+func (goFormatter) WriteResponse(w io.Writer, rec *Record) error {
+	return nil
+}
+
+//-------------------------------------------------
+
+type jsonFormatter struct{}
+
+type jsonRequest struct {
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	Proto         string      `json:"proto"`
+	Host          string      `json:"host,omitempty"`
+	RemoteAddr    string      `json:"remoteAddr,omitempty"`
+	Header        http.Header `json:"header"`
+	Body          string      `json:"body,omitempty"`
+	BodyEncoding  string      `json:"bodyEncoding,omitempty"`
+	BodyTruncated int         `json:"bodyTruncated,omitempty"`
+	Parts         []*jsonPart `json:"parts,omitempty"`
+	Websocket     bool        `json:"websocket,omitempty"`
+}
+
+type jsonPart struct {
+	Name           string `json:"name"`
+	Filename       string `json:"filename,omitempty"`
+	ContentType    string `json:"contentType,omitempty"`
+	Size           int    `json:"size"`
+	Value          string `json:"value,omitempty"`
+	ValueEncoding  string `json:"valueEncoding,omitempty"`
+	ValueTruncated int    `json:"valueTruncated,omitempty"`
+}
+
+type jsonResponse struct {
+	Status        int         `json:"status"`
+	Header        http.Header `json:"header"`
+	Trailer       http.Header `json:"trailer,omitempty"`
+	Body          string      `json:"body,omitempty"`
+	BodyEncoding  string      `json:"bodyEncoding,omitempty"`
+	BodyTruncated int         `json:"bodyTruncated,omitempty"`
+	Hijacked      bool        `json:"hijacked,omitempty"`
+	Error         string      `json:"error,omitempty"`
+}
+
+type jsonRecord struct {
+	ID       string        `json:"id"`
+	ParentID string        `json:"parentId,omitempty"`
+	Seq      uint64        `json:"seq"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration float64       `json:"duration"`
+	Request  *jsonRequest  `json:"request"`
+	Response *jsonResponse `json:"response"`
+}
+
+// WriteRequest do nothing, the whole record is written by WriteResponse.
+func (jsonFormatter) WriteRequest(w io.Writer, rec *Record) error {
+	return nil
+}
+
+func (jsonFormatter) WriteResponse(w io.Writer, rec *Record) error {
+	bb := &bytes.Buffer{}
+	enc := json.NewEncoder(bb)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(newJSONRecord(rec)); err != nil {
+		return err
+	}
+
+	_, err := w.Write(bb.Bytes())
+	return err
+}
+
+func newJSONRecord(rec *Record) *jsonRecord {
+	jr := &jsonRecord{
+		ID:       rec.ID,
+		ParentID: rec.ParentID,
+		Seq:      rec.Seq,
+		Start:    rec.Start,
+		End:      rec.End,
+		Duration: float64(rec.Duration()) / float64(time.Millisecond),
+		Request: &jsonRequest{
+			Method:        rec.Method,
+			URL:           rec.URL,
+			Proto:         rec.Proto,
+			Host:          rec.Host,
+			RemoteAddr:    rec.RemoteAddr,
+			Header:        rec.RequestHeader,
+			BodyTruncated: rec.RequestTruncated,
+			Websocket:     rec.Websocket,
+		},
+		Response: &jsonResponse{
+			Status:        rec.Status,
+			Header:        rec.ResponseHeader,
+			Trailer:       rec.ResponseTrailer,
+			BodyTruncated: rec.ResponseTruncated,
+			Hijacked:      rec.Hijacked,
+			Error:         rec.Error,
+		},
+	}
+	jr.Request.Body, jr.Request.BodyEncoding = encodeBody(rec.RequestBody)
+	for _, p := range rec.RequestParts {
+		jp := &jsonPart{
+			Name:           p.Name,
+			Filename:       p.Filename,
+			ContentType:    p.ContentType,
+			Size:           p.Size,
+			ValueTruncated: p.Truncated(),
+		}
+		jp.Value, jp.ValueEncoding = encodeBody(p.Value)
+		jr.Request.Parts = append(jr.Request.Parts, jp)
+	}
+	jr.Response.Body, jr.Response.BodyEncoding = encodeBody(rec.ResponseBody)
+	return jr
+}
+
+// encodeBody returns the body string and the encoding ("utf-8" or "base64")
+func encodeBody(body []byte) (string, string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+	if utf8.Valid(body) {
+		return string(body), "utf-8"
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
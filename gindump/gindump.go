@@ -1,14 +1,16 @@
 package gindump
 
-//nolint: gosec
 import (
 	"bytes"
-	"crypto/sha1"
-	"fmt"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/http/httputil"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,15 +18,55 @@ import (
 
 const defaultTimeFormat = "2006-01-02T15:04:05.000"
 
+// ContextKey the gin context key of the dumped exchange id
+var ContextKey = "GINDUMP_ID"
+
+// contextKey the request context key of the dumped exchange id
+type contextKey struct{}
+
+// GetID get the dumped exchange id from gin.Context
+func GetID(c *gin.Context) string {
+	if v, ok := c.Get(ContextKey); ok {
+		return v.(string)
+	}
+	return ""
+}
+
+// getID get the dumped exchange id from the context (gin.Context or the request context)
+func getID(ctx context.Context) string {
+	if v, ok := ctx.Value(contextKey{}).(string); ok {
+		return v
+	}
+	if v, ok := ctx.Value(ContextKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
 // Dumper dump http request and response
 type Dumper struct {
-	outputer io.Writer
-	disabled bool
+	// seq the sequence number of the last dumped exchange (must be 64-bit aligned)
+	seq uint64
+
+	outputer  io.Writer
+	formatter Formatter
+	recorder  Recorder
+	redactor  *redactor
+	trigger   *trigger
+	maxBody   int
+	maxFile   int
+	atomic    bool
+	disabled  bool
+
+	// mutex serialize the writes to the outputer
+	mutex sync.Mutex
 }
 
 // New create a log middleware for gin http dumper
+// The sensitive data are redacted by DefaultRedactHeaders, DefaultRedactCookies,
+// DefaultRedactFields and DefaultRedactJSONPaths.
 func New(outputer io.Writer) *Dumper {
-	return &Dumper{outputer: outputer}
+	return &Dumper{outputer: outputer, formatter: TextFormatter, redactor: newRedactor()}
 }
 
 // Disable disable the dumper or not
@@ -32,6 +74,59 @@ func (d *Dumper) Disable(disabled bool) {
 	d.disabled = disabled
 }
 
+// Atomic emit the request and response of a exchange as a single record or not.
+// If atomic is false (default), the request is written before it is processed
+// and the response is written after it is processed, so the records of the
+// concurrent requests may be interleaved in the output.
+// If atomic is true, the request and response are written together by a single
+// Write() call after the request is processed.
+func (d *Dumper) Atomic(atomic bool) {
+	d.atomic = atomic
+}
+
+// SetTrigger only dump the requests carrying the trigger header or query parameter whose
+// value is the secret or a valid token created by NewTriggerToken(secret, expires),
+// all other requests are not dumped.
+// The outbound requests of the Transport() are dumped only if the inbound request is dumped.
+// The empty header or param disables the trigger source, the empty secret disables the trigger.
+// The trigger header and parameter values are always redacted.
+//
+//	dumper.SetTrigger(gindump.TriggerHeader, gindump.TriggerParam, "secret")
+//	token := gindump.NewTriggerToken("secret", time.Now().Add(time.Hour))
+//	// curl -H "X-Gindump-Token: $token" https://example.com/api
+func (d *Dumper) SetTrigger(header, param, secret string) {
+	rd := *d.redactor
+	if secret == "" {
+		d.trigger = nil
+		rd.triggerHeader, rd.triggerParam = "", ""
+	} else {
+		d.trigger = &trigger{header: header, param: param, secret: []byte(secret)}
+		rd.triggerHeader, rd.triggerParam = http.CanonicalHeaderKey(header), param
+	}
+	d.redactor = &rd
+}
+
+// SetMaxBodySize set the maximum size of the request and response body to dump.
+// The exceeded part of the body is truncated from the dump (not from the exchange).
+// Only the first size bytes of the request body are buffered before the request is processed,
+// the rest is streamed to the handlers, so it's useful for the large uploads.
+// It's also useful for the long lived streaming (SSE) responses,
+// because the response body is buffered until the request is processed.
+// Default: 0 (unlimited, the whole request body is buffered)
+func (d *Dumper) SetMaxBodySize(size int) {
+	d.maxBody = size
+}
+
+// SetMaxFileSize set the maximum size of the file content to dump for the
+// multipart/form-data request. The multipart body is dumped as a summary of
+// the parts (field name, file name, content type, size and text field value).
+// The summary is made from the buffered request body, so the parts after the max body size
+// (see SetMaxBodySize) are not summarized.
+// Default: 0 (the file content is omitted)
+func (d *Dumper) SetMaxFileSize(size int) {
+	d.maxFile = size
+}
+
 // Handler returns the gin.HandlerFunc
 func (d *Dumper) Handler() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -41,27 +136,40 @@ func (d *Dumper) Handler() gin.HandlerFunc {
 
 // handle process gin request
 func (d *Dumper) handle(c *gin.Context) {
-	w := d.outputer
-	if w == nil || d.disabled {
+	if !d.enabled() || (d.trigger != nil && !d.trigger.match(c.Request)) {
 		c.Next()
 		return
 	}
 
+	ex := d.newExchange("")
+	ex.rec.Route = c.FullPath()
+
 	// dump request
-	id := dumpRequest(w, c.Request)
+	ex.dumpRequest(c.Request)
+
+	// set the exchange id to the context for the outbound requests
+	c.Set(ContextKey, ex.rec.ID)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), contextKey{}, ex.rec.ID))
 
-	dw := &dumpWriter{c.Writer, &http.Response{
-		Proto:      c.Request.Proto,
-		ProtoMajor: c.Request.ProtoMajor,
-		ProtoMinor: c.Request.ProtoMinor,
-	}, &bytes.Buffer{}}
+	dw := &dumpWriter{ResponseWriter: c.Writer, ex: ex}
 	c.Writer = dw
 
 	// process request
 	c.Next()
 
-	// dump response
-	dumpResponse(w, id, dw)
+	// dump response (if the connection is not hijacked)
+	ex.finish(dw.fill)
+}
+
+// enabled returns true if the dumper is not disabled and has a output or recorder
+func (d *Dumper) enabled() bool {
+	return !d.disabled && (d.outputer != nil || d.recorder != nil)
+}
+
+func (d *Dumper) write(w io.Writer, data []byte) {
+	d.mutex.Lock()
+	w.Write(data) //nolint: errcheck
+	d.mutex.Unlock()
 }
 
 // SetOutput set the access log output writer
@@ -69,51 +177,220 @@ func (d *Dumper) SetOutput(w io.Writer) {
 	d.outputer = w
 }
 
-const eol = "\r\n"
+// SetRecorder set the recorder which records the dumped exchanges (e.g. RingBuffer).
+// The recorder works with the output writer, the output writer can be nil if
+// only the recorder is used.
+func (d *Dumper) SetRecorder(r Recorder) {
+	d.recorder = r
+}
+
+// SetFormatter set the dump formatter.
+// Default: TextFormatter
+func (d *Dumper) SetFormatter(f Formatter) {
+	d.formatter = f
+}
+
+// SetRedactHeaders set the header names whose values are redacted.
+// Default: DefaultRedactHeaders
+func (d *Dumper) SetRedactHeaders(names ...string) {
+	rd := *d.redactor
+	rd.setHeaders(names...)
+	d.redactor = &rd
+}
+
+// SetRedactCookies set the cookie names whose values are redacted in the
+// "Cookie" and "Set-Cookie" headers. The special value "*" matches any cookie.
+// Default: DefaultRedactCookies
+func (d *Dumper) SetRedactCookies(names ...string) {
+	rd := *d.redactor
+	rd.setCookies(names...)
+	d.redactor = &rd
+}
+
+// SetRedactFields set the form field (and URL query parameter) names whose values are redacted.
+// The names are case insensitive.
+// Default: DefaultRedactFields
+func (d *Dumper) SetRedactFields(names ...string) {
+	rd := *d.redactor
+	rd.setFields(names...)
+	d.redactor = &rd
+}
 
-func dumpRequest(w io.Writer, req *http.Request) string {
-	bs, _ := httputil.DumpRequest(req, true)
+// SetRedactJSONPaths set the JSON paths of the request and response JSON body whose values are redacted.
+// Supported syntax: "$.name", "$..name" (recursive descent), "$.*", "$['name']", "$.list[0]", "$.list[*]".
+// For example: "$.password", "$.card.number", "$..token".
+// It panics if a path is invalid.
+// Default: DefaultRedactJSONPaths
+func (d *Dumper) SetRedactJSONPaths(paths ...string) {
+	rd := *d.redactor
+	rd.setJSONPaths(paths...)
+	d.redactor = &rd
+}
 
-	id := fmt.Sprintf("%x", sha1.Sum(bs)) //nolint: gosec
+// newID returns a random unique id (128 bits hex string)
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
 
-	bb := &bytes.Buffer{}
+// exchange a dumping request/response exchange
+type exchange struct {
+	outputer  io.Writer
+	formatter Formatter
+	recorder  Recorder
+	redactor  *redactor
+	maxBody   int
+	maxFile   int
+	atomic    bool
+	dumper    *Dumper
+	rest      *countReader // the unbuffered rest of the request body
+	rec       *Record
+	bb        bytes.Buffer
+	once      sync.Once
+}
 
-	bb.WriteString(fmt.Sprintf(">>>>>>>> %s %s >>>>>>>>", time.Now().Format(defaultTimeFormat), id))
-	bb.WriteString(eol)
-	if len(bs) > 0 {
-		bb.Write(bs)
+func (d *Dumper) newExchange(parentID string) *exchange {
+	return &exchange{
+		outputer:  d.outputer,
+		formatter: d.formatter,
+		recorder:  d.recorder,
+		redactor:  d.redactor,
+		maxBody:   d.maxBody,
+		maxFile:   d.maxFile,
+		atomic:    d.atomic,
+		dumper:    d,
+		rec: &Record{
+			ID:       newID(),
+			ParentID: parentID,
+			Seq:      atomic.AddUint64(&d.seq, 1),
+			Start:    time.Now(),
+		},
 	}
-	bb.WriteString(eol)
-	bb.WriteString(eol)
+}
 
-	w.Write(bb.Bytes()) //nolint: errcheck
+// flush write the buffered dump to the outputer
+func (ex *exchange) flush() {
+	if ex.bb.Len() > 0 {
+		ex.dumper.write(ex.outputer, ex.bb.Bytes())
+		ex.bb.Reset()
+	}
+}
 
-	return id
+// dumpRequest dump the request (read and restore the request body)
+func (ex *exchange) dumpRequest(req *http.Request) {
+	body, rest := readBody(req, ex.maxBody)
+	ex.rest = rest
+	ex.rec.setRequest(req, body, rest != nil, ex.redactor, ex.maxFile)
+	if rest != nil && req.ContentLength > int64(len(body)) {
+		ex.rec.RequestTruncated = int(req.ContentLength) - len(body)
+	}
+	if !ex.atomic && ex.outputer != nil {
+		ex.formatter.WriteRequest(&ex.bb, ex.rec) //nolint: errcheck
+		ex.flush()
+	}
 }
 
-func dumpResponse(w io.Writer, id string, dw *dumpWriter) {
-	bb := &bytes.Buffer{}
+// finish set the response part of the record by fill() and dump it.
+// Only the first call of finish() takes effect.
+func (ex *exchange) finish(fill func(rec *Record)) {
+	ex.once.Do(func() {
+		rec := ex.rec
+		rec.End = time.Now()
+		if ex.rest != nil {
+			if n := ex.rest.count(); n > rec.RequestTruncated {
+				rec.RequestTruncated = n
+			}
+		}
+		fill(rec)
 
-	bb.WriteString(fmt.Sprintf("<<<<<<<< %s %s <<<<<<<<", time.Now().Format(defaultTimeFormat), id))
-	bb.WriteString(eol)
+		if ex.outputer != nil {
+			if ex.atomic {
+				ex.formatter.WriteRequest(&ex.bb, rec) //nolint: errcheck
+			}
+			ex.formatter.WriteResponse(&ex.bb, rec) //nolint: errcheck
+			ex.flush()
+		}
+		if ex.recorder != nil {
+			ex.recorder.Record(rec)
+		}
+	})
+}
 
-	dw.res.StatusCode = dw.ResponseWriter.Status()
-	dw.res.Header = dw.ResponseWriter.Header()
-	dw.res.Body = ioutil.NopCloser(dw.bb)
-	dw.res.Write(bb) //nolint: errcheck
-	bb.WriteString(eol)
-	bb.WriteString(eol)
+// capture append the data to the buffer bb within the max size
+func capture(bb *bytes.Buffer, data []byte, max int) {
+	if max > 0 {
+		n := max - bb.Len()
+		if n <= 0 {
+			return
+		}
+		if len(data) > n {
+			data = data[:n]
+		}
+	}
+	bb.Write(data)
+}
+
+// readBody read the request body up to max bytes (0: unlimited) and restore it for the handlers.
+// If the body may exceed the max size, the rest of the body is not buffered but streamed to the handlers,
+// and a countReader of the rest is returned to count the truncated size.
+func readBody(req *http.Request, max int) ([]byte, *countReader) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	var r io.Reader = req.Body
+	if max > 0 {
+		r = io.LimitReader(req.Body, int64(max))
+	}
+
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		return body, nil
+	}
+	if max <= 0 || len(body) < max {
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return body, nil
+	}
+
+	rest := &countReader{r: req.Body}
+	req.Body = &readCloser{io.MultiReader(bytes.NewReader(body), rest), req.Body}
+	return body, rest
+}
+
+// readCloser a io.ReadCloser of the separated reader and closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// countReader a reader which counts the read bytes
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	atomic.AddInt64(&cr.n, int64(n))
+	return n, err
+}
 
-	w.Write(bb.Bytes()) //nolint: errcheck
+// count returns the read bytes
+func (cr *countReader) count() int {
+	return int(atomic.LoadInt64(&cr.n))
 }
 
-type dumpWriter struct {
-	gin.ResponseWriter
-	res *http.Response
-	bb  *bytes.Buffer
+// errReader a reader always returns the error
+type errReader struct {
+	err error
 }
 
-func (dw *dumpWriter) Write(data []byte) (int, error) {
-	dw.bb.Write(data)
-	return dw.ResponseWriter.Write(data)
+func (er errReader) Read(p []byte) (int, error) {
+	return 0, er.err
 }
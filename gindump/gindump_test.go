@@ -2,13 +2,17 @@ package gindump
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yffrankwang/ginx/str"
@@ -77,3 +81,321 @@ func TestHttpDump(t *testing.T) {
 	performRequest(router, "GET", "/notfound")
 	assertContains(t, "GET /notfound", buffer.String(), "GET /notfound HTTP/1.1", "HTTP/1.1 404 Not Found")
 }
+
+func TestHttpDumpAtomic(t *testing.T) {
+	router := gin.New()
+
+	ow := &writeRecorder{}
+	dumper := New(ow)
+	dumper.Atomic(true)
+	router.Use(dumper.Handler())
+
+	router.GET("/example", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/example", nil))
+		}()
+	}
+	wg.Wait()
+
+	if len(ow.writes) != 10 {
+		t.Fatalf("len(writes) = %d, want %d", len(ow.writes), 10)
+	}
+
+	ids := map[string]bool{}
+	seqs := map[string]bool{}
+	for _, s := range ow.writes {
+		assertContains(t, "GET /example", s, "GET /example HTTP/1.1", "HTTP/1.1 200 OK")
+
+		ss := strings.Fields(strings.SplitN(s, "\r\n", 2)[0])
+		rs := strings.Fields(s[strings.Index(s, "<<<<<<<<"):])
+		if ss[2] != rs[2] || ss[3] != rs[3] {
+			t.Errorf("request %v %v != response %v %v", ss[2], ss[3], rs[2], rs[3])
+		}
+		if ids[ss[3]] {
+			t.Errorf("duplicated id %v", ss[3])
+		}
+		if seqs[ss[2]] {
+			t.Errorf("duplicated sequence %v", ss[2])
+		}
+		ids[ss[3]] = true
+		seqs[ss[2]] = true
+	}
+	for i := 1; i <= 10; i++ {
+		if !seqs["#"+strconv.Itoa(i)] {
+			t.Errorf("missing sequence #%d", i)
+		}
+	}
+}
+
+type writeRecorder struct {
+	writes []string
+}
+
+func (wr *writeRecorder) Write(data []byte) (int, error) {
+	wr.writes = append(wr.writes, string(data))
+	return len(data), nil
+}
+
+func TestHttpDumpWriteString(t *testing.T) {
+	router := gin.New()
+
+	buffer := new(bytes.Buffer)
+	router.Use(New(buffer).Handler())
+
+	router.GET("/string", func(c *gin.Context) {
+		c.Writer.WriteString("write-string") //nolint: errcheck
+	})
+	router.GET("/copy", func(c *gin.Context) {
+		io.Copy(c.Writer, strings.NewReader("read-from")) //nolint: errcheck
+	})
+
+	performRequest(router, "GET", "/string")
+	assertContains(t, "GET /string", buffer.String(), "HTTP/1.1 200 OK", "write-string")
+
+	buffer.Reset()
+	performRequest(router, "GET", "/copy")
+	assertContains(t, "GET /copy", buffer.String(), "HTTP/1.1 200 OK", "read-from")
+}
+
+func TestHttpDumpElapsedAndTrailer(t *testing.T) {
+	router := gin.New()
+
+	buffer := new(bytes.Buffer)
+	router.Use(New(buffer).Handler())
+
+	router.GET("/trailer", func(c *gin.Context) {
+		c.Header("Trailer", "X-Checksum")
+		time.Sleep(time.Millisecond * 10)
+		c.String(http.StatusOK, "body")
+		c.Header("X-Checksum", "abc")
+		c.Header(http.TrailerPrefix+"X-Extra", "def")
+	})
+
+	performRequest(router, "GET", "/trailer")
+
+	dump := buffer.String()
+	assertContains(t, "GET /trailer", dump, "body\r\n\r\nX-Checksum: abc\r\nX-Extra: def\r\n")
+
+	rs := strings.Fields(dump[strings.Index(dump, "<<<<<<<<"):])
+	if d, err := time.ParseDuration(rs[4]); err != nil || d < time.Millisecond*10 {
+		t.Errorf("elapsed = %q, want >= 10ms", rs[4])
+	}
+}
+
+func TestHttpDumpStream(t *testing.T) {
+	router := gin.New()
+
+	buffer := new(bytes.Buffer)
+	router.Use(New(buffer).Handler())
+
+	router.GET("/sse", func(c *gin.Context) {
+		for i := 0; i < 3; i++ {
+			c.SSEvent("message", i)
+			c.Writer.Flush()
+		}
+	})
+
+	w := performRequest(router, "GET", "/sse")
+	if !w.Flushed {
+		t.Error("response is not flushed")
+	}
+	assertContains(t, "GET /sse", buffer.String(), "Content-Type: text/event-stream", "data:0\n\n", "data:2\n\n")
+}
+
+func TestHttpDumpMaxBodySize(t *testing.T) {
+	router := gin.New()
+
+	buffer := new(bytes.Buffer)
+	dumper := New(buffer)
+	dumper.SetMaxBodySize(10)
+	router.Use(dumper.Handler())
+
+	router.POST("/large", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, strings.Repeat("b", len(body)))
+	})
+
+	req := httptest.NewRequest("POST", "/large", strings.NewReader(strings.Repeat("a", 100)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Body.Len() != 100 {
+		t.Errorf("len(body) = %d, want %d", w.Body.Len(), 100)
+	}
+
+	dump := buffer.String()
+	assertContains(t, "POST /large", dump, strings.Repeat("a", 10)+"\r\n... (90 bytes truncated)", strings.Repeat("b", 10)+"\r\n... (90 bytes truncated)")
+	if strings.Contains(dump, strings.Repeat("a", 11)) || strings.Contains(dump, strings.Repeat("b", 11)) {
+		t.Errorf("http dump is not truncated: %q", dump)
+	}
+}
+
+// countingReader counts the read bytes
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += n
+	return n, err
+}
+
+func TestHttpDumpMaxBodySizeStream(t *testing.T) {
+	rb := NewRingBuffer(1)
+	dumper := New(nil)
+	dumper.SetRecorder(rb)
+	dumper.SetMaxBodySize(10)
+
+	cr := &countingReader{r: strings.NewReader(strings.Repeat("a", 100))}
+
+	router := gin.New()
+	router.Use(dumper.Handler())
+	router.POST("/large", func(c *gin.Context) {
+		if cr.n != 10 {
+			t.Errorf("the request body is read %d bytes before the handler, want %d", cr.n, 10)
+		}
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%d", len(body))
+	})
+
+	// unknown content length
+	req := httptest.NewRequest("POST", "/large", cr)
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Body.String() != "100" {
+		t.Errorf("body = %q, want %q", w.Body.String(), "100")
+	}
+
+	rec := rb.Records()[0]
+	if string(rec.RequestBody) != strings.Repeat("a", 10) || rec.RequestTruncated != 90 {
+		t.Errorf("RequestBody = %q, RequestTruncated = %d", rec.RequestBody, rec.RequestTruncated)
+	}
+}
+
+func TestHttpDumpMaxBodySizeRedacted(t *testing.T) {
+	rb := NewRingBuffer(10)
+	dumper := New(nil)
+	dumper.SetRecorder(rb)
+	dumper.SetMaxBodySize(10)
+
+	router := gin.New()
+	router.Use(dumper.Handler())
+	router.POST("/login", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	// the redacted body is longer than the max body size, but it is not truncated
+	req := httptest.NewRequest("POST", "/login", strings.NewReader("password=a"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := rb.Records()[0]
+	if string(rec.RequestBody) != "password="+Redacted || rec.RequestTruncated != 0 {
+		t.Errorf("RequestBody = %q, RequestTruncated = %d", rec.RequestBody, rec.RequestTruncated)
+	}
+}
+
+func TestHttpDumpHijack(t *testing.T) {
+	router := gin.New()
+
+	buffer := new(bytes.Buffer)
+	router.Use(New(buffer).Handler())
+
+	done := make(chan struct{})
+	router.GET("/ws", func(c *gin.Context) {
+		conn, rw, err := c.Writer.Hijack()
+		if err != nil {
+			t.Errorf("Hijack() = %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// the response is dumped before the hijacked connection is closed
+		assertContains(t, "GET /ws", buffer.String(), "[connection hijacked: websocket upgrade]")
+		close(done)
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n") //nolint: errcheck
+		rw.Flush()                                                                                              //nolint: errcheck
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	<-done
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("StatusCode = %d, want %d", res.StatusCode, http.StatusSwitchingProtocols)
+	}
+	if n := strings.Count(buffer.String(), "<<<<<<<<"); n != 2 {
+		t.Errorf("response dump count = %d, want %d", n, 2)
+	}
+}
+
+func TestHttpDumpJSON(t *testing.T) {
+	router := gin.New()
+
+	buffer := new(bytes.Buffer)
+	dumper := New(buffer)
+	dumper.SetFormatter(JSONFormatter)
+	router.Use(dumper.Handler())
+
+	router.POST("/json", func(c *gin.Context) {
+		c.Data(http.StatusCreated, "application/octet-stream", []byte{0xff, 0xfe, 0x00})
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/json?a=1", strings.NewReader(`{"name":"n","password":"p"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("len(lines) = %d, want %d: %q", len(lines), 2, buffer.String())
+	}
+
+	for i, line := range lines {
+		jr := &jsonRecord{}
+		if err := json.Unmarshal([]byte(line), jr); err != nil {
+			t.Fatalf("json.Unmarshal(%q) = %v", line, err)
+		}
+
+		if jr.ID == "" || jr.Seq != uint64(i+1) || jr.Start.IsZero() || jr.End.Before(jr.Start) {
+			t.Errorf("invalid record: %q", line)
+		}
+		if jr.Request.Method != "POST" || jr.Request.URL != "/json?a=1" || jr.Request.Proto != "HTTP/1.1" {
+			t.Errorf("invalid request: %q", line)
+		}
+		if jr.Request.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("invalid request header: %q", line)
+		}
+		if jr.Request.Body != `{"name":"n","password":"******"}` || jr.Request.BodyEncoding != "utf-8" {
+			t.Errorf("invalid request body: %q", line)
+		}
+		if jr.Response.Status != http.StatusCreated || jr.Response.Header.Get("Content-Type") != "application/octet-stream" {
+			t.Errorf("invalid response: %q", line)
+		}
+		if jr.Response.Body != "//4A" || jr.Response.BodyEncoding != "base64" {
+			t.Errorf("invalid response body: %q", line)
+		}
+	}
+}
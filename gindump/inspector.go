@@ -0,0 +1,261 @@
+package gindump
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mount mount the in-process request inspector routes of the ring buffer to the router group.
+//
+//	GET    /           list the exchanges (newest first)
+//	DELETE /           clear the exchanges
+//	GET    /:id        show the exchange detail
+//	GET    /:id/curl   show the request as a curl command line
+//
+// The list can be filtered by the query parameters:
+//
+//	path   - the URL path contains the value
+//	method - the request method
+//	status - the response status code ("404") or class ("4xx")
+//
+// The JSON is returned if the query parameter "format" is "json" or the "Accept" header prefers JSON,
+// otherwise the HTML is returned.
+// The inspector exposes the captured (redacted) requests, it should be only mounted for the
+// staging environments or be protected by a authentication middleware.
+// Mount the inspector to a group without the dumper middleware, otherwise the inspector
+// requests are recorded too.
+//
+//	rb := gindump.NewRingBuffer(100)
+//	dumper := gindump.New(nil)
+//	dumper.SetRecorder(rb)
+//	rb.Mount(router.Group("/_dump", gin.BasicAuth(accounts)))
+//	api := router.Group("/api", dumper.Handler())
+func (rb *RingBuffer) Mount(g *gin.RouterGroup) {
+	ri := &inspector{rb: rb, base: strings.TrimSuffix(g.BasePath(), "/")}
+
+	g.GET("/", ri.list)
+	g.DELETE("/", ri.clear)
+	g.GET("/:id", ri.detail)
+	g.GET("/:id/curl", ri.curl)
+}
+
+type inspector struct {
+	rb   *RingBuffer
+	base string
+}
+
+// recordFilter the filter of the record list
+type recordFilter struct {
+	Path   string
+	Method string
+	Status string
+}
+
+func (rf *recordFilter) match(rec *Record) bool {
+	if rf.Path != "" && !strings.Contains(rec.Path(), rf.Path) {
+		return false
+	}
+	if rf.Method != "" && !strings.EqualFold(rf.Method, rec.Method) {
+		return false
+	}
+	if rf.Status != "" {
+		ss := strconv.Itoa(rec.Status)
+		if len(rf.Status) == 3 && strings.HasSuffix(strings.ToLower(rf.Status), "xx") {
+			return ss[0] == rf.Status[0]
+		}
+		return ss == rf.Status
+	}
+	return true
+}
+
+func wantJSON(c *gin.Context) bool {
+	if f := c.Query("format"); f != "" {
+		return f == "json"
+	}
+	return c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+}
+
+type jsonSummary struct {
+	ID       string    `json:"id"`
+	ParentID string    `json:"parentId,omitempty"`
+	Seq      uint64    `json:"seq"`
+	Start    time.Time `json:"start"`
+	Duration float64   `json:"duration"`
+	Method   string    `json:"method"`
+	URL      string    `json:"url"`
+	Status   int       `json:"status"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func (ri *inspector) list(c *gin.Context) {
+	rf := &recordFilter{
+		Path:   c.Query("path"),
+		Method: c.Query("method"),
+		Status: c.Query("status"),
+	}
+
+	recs := []*Record{}
+	for _, rec := range ri.rb.Records() {
+		if rf.match(rec) {
+			recs = append(recs, rec)
+		}
+	}
+
+	if wantJSON(c) {
+		jss := make([]*jsonSummary, len(recs))
+		for i, rec := range recs {
+			jss[i] = &jsonSummary{
+				ID:       rec.ID,
+				ParentID: rec.ParentID,
+				Seq:      rec.Seq,
+				Start:    rec.Start,
+				Duration: float64(rec.Duration()) / float64(time.Millisecond),
+				Method:   rec.Method,
+				URL:      rec.URL,
+				Status:   rec.Status,
+				Error:    rec.Error,
+			}
+		}
+		c.JSON(http.StatusOK, jss)
+		return
+	}
+
+	ri.html(c, "list", gin.H{"Base": ri.base, "Filter": rf, "Records": recs})
+}
+
+func (ri *inspector) clear(c *gin.Context) {
+	ri.rb.Clear()
+	c.Status(http.StatusNoContent)
+}
+
+func (ri *inspector) detail(c *gin.Context) {
+	rec := ri.rb.Get(c.Param("id"))
+	if rec == nil {
+		c.String(http.StatusNotFound, "exchange %s not found", c.Param("id"))
+		return
+	}
+
+	if wantJSON(c) {
+		c.JSON(http.StatusOK, newJSONRecord(rec))
+		return
+	}
+
+	req, res := &bytes.Buffer{}, &bytes.Buffer{}
+	TextFormatter.WriteRequest(req, rec)  //nolint: errcheck
+	TextFormatter.WriteResponse(res, rec) //nolint: errcheck
+
+	ri.html(c, "detail", gin.H{
+		"Base":     ri.base,
+		"Record":   rec,
+		"Request":  req.String(),
+		"Response": res.String(),
+		"Curl":     curlCommand(rec),
+	})
+}
+
+func (ri *inspector) curl(c *gin.Context) {
+	rec := ri.rb.Get(c.Param("id"))
+	if rec == nil {
+		c.String(http.StatusNotFound, "exchange %s not found", c.Param("id"))
+		return
+	}
+
+	c.String(http.StatusOK, curlCommand(rec))
+}
+
+func (ri *inspector) html(c *gin.Context, name string, data interface{}) {
+	bb := &bytes.Buffer{}
+	if err := inspectorTemplates.ExecuteTemplate(bb, name, data); err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", bb.Bytes())
+}
+
+var inspectorTemplates = template.Must(template.New("inspector").Funcs(template.FuncMap{
+	"time": func(t time.Time) string {
+		return t.Format(defaultTimeFormat)
+	},
+	"status": func(rec *Record) string {
+		switch {
+		case rec.Error != "":
+			return "ERROR"
+		case rec.Hijacked:
+			return "HIJACKED"
+		default:
+			return strconv.Itoa(rec.Status)
+		}
+	},
+}).Parse(`
+{{define "style"}}
+<style>
+body { font-family: sans-serif; font-size: 14px; margin: 1em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #eee; }
+pre { background: #f6f6f6; border: 1px solid #ccc; padding: 8px; white-space: pre-wrap; word-break: break-all; }
+.s2 { color: green; } .s3 { color: blue; } .s4 { color: orange; } .s5, .sE { color: red; }
+</style>
+{{end}}
+
+{{define "list"}}<!DOCTYPE html>
+<html>
+<head><title>gindump</title>{{template "style"}}</head>
+<body>
+<h1>gindump</h1>
+<form method="GET" action="{{.Base}}/">
+	Path: <input name="path" value="{{.Filter.Path}}">
+	Method: <input name="method" value="{{.Filter.Method}}" size="8">
+	Status: <input name="status" value="{{.Filter.Status}}" size="4">
+	<button type="submit">Filter</button>
+	<a href="{{.Base}}/?format=json">JSON</a>
+</form>
+<br>
+<table>
+<tr><th>#</th><th>Time</th><th>Method</th><th>URL</th><th>Status</th><th>Duration</th><th>ID</th></tr>
+{{range .Records}}
+<tr>
+	<td>{{.Seq}}</td>
+	<td>{{time .Start}}</td>
+	<td>{{.Method}}</td>
+	<td>{{.URL}}</td>
+	<td class="s{{slice (status .) 0 1}}">{{status .}}</td>
+	<td>{{.Duration}}</td>
+	<td><a href="{{$.Base}}/{{.ID}}">{{.ID}}</a>{{if .ParentID}} &larr; <a href="{{$.Base}}/{{.ParentID}}">{{.ParentID}}</a>{{end}}</td>
+</tr>
+{{end}}
+</table>
+</body>
+</html>
+{{end}}
+
+{{define "detail"}}<!DOCTYPE html>
+<html>
+<head><title>gindump - {{.Record.ID}}</title>{{template "style"}}</head>
+<body>
+<h1><a href="{{.Base}}/">gindump</a> / {{.Record.ID}}</h1>
+<table>
+<tr><th>Sequence</th><td>{{.Record.Seq}}</td></tr>
+{{if .Record.ParentID}}<tr><th>Parent</th><td><a href="{{.Base}}/{{.Record.ParentID}}">{{.Record.ParentID}}</a></td></tr>{{end}}
+<tr><th>Start</th><td>{{time .Record.Start}}</td></tr>
+<tr><th>End</th><td>{{time .Record.End}}</td></tr>
+<tr><th>Duration</th><td>{{.Record.Duration}}</td></tr>
+<tr><th>Remote Address</th><td>{{.Record.RemoteAddr}}</td></tr>
+</table>
+<h2>Request</h2>
+<pre>{{.Request}}</pre>
+<h2>Response</h2>
+<pre>{{.Response}}</pre>
+<h2>curl <button onclick="navigator.clipboard.writeText(document.getElementById('curl').textContent)">Copy as curl</button></h2>
+<pre id="curl">{{.Curl}}</pre>
+<a href="{{.Base}}/{{.Record.ID}}?format=json">JSON</a>
+</body>
+</html>
+{{end}}
+`))
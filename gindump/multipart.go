@@ -0,0 +1,140 @@
+package gindump
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+)
+
+// Part a summarized part of the multipart/form-data request body
+type Part struct {
+	Name        string // the form field name
+	Filename    string // the file name of a file part
+	ContentType string // the content type of the part
+	Size        int    // the size of the part content
+	Value       []byte // the value of a text field, or the (truncated) content of a file part
+}
+
+// IsFile returns true if the part is a file part
+func (p *Part) IsFile() bool {
+	return p.Filename != ""
+}
+
+// Truncated returns the truncated size of the file part content.
+// It returns 0 for the text field, the value of the text field is not truncated but may be redacted.
+func (p *Part) Truncated() int {
+	if !p.IsFile() || p.Size < len(p.Value) {
+		return 0
+	}
+	return p.Size - len(p.Value)
+}
+
+func isMultipartType(ct string) bool {
+	return mediaType(ct) == "multipart/form-data"
+}
+
+// parseMultipart parse the multipart/form-data body to parts without the file contents.
+// At most maxFile bytes of the file content are kept (0: omit the file content).
+// The text field values are redacted by the redactor.
+// The parsed parts and the error of the malformed body are returned.
+func parseMultipart(ct string, body []byte, rd *redactor, maxFile int) ([]*Part, error) {
+	_, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return nil, err
+	}
+	boundary := params["boundary"]
+	if boundary == "" {
+		return nil, errors.New("gindump: missing multipart boundary")
+	}
+
+	parts := []*Part{}
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		mp, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return parts, nil
+		}
+		if err != nil {
+			return parts, err
+		}
+
+		p := &Part{
+			Name:        mp.FormName(),
+			Filename:    mp.FileName(),
+			ContentType: mp.Header.Get("Content-Type"),
+		}
+
+		bb := &bytes.Buffer{}
+		max := maxFile
+		if !p.IsFile() {
+			max = 0
+		}
+
+		var n int64
+		if p.IsFile() && max <= 0 {
+			n, err = io.Copy(io.Discard, mp)
+		} else if p.IsFile() {
+			n, err = io.Copy(bb, io.LimitReader(mp, int64(max)))
+			if err == nil {
+				var m int64
+				m, err = io.Copy(io.Discard, mp)
+				n += m
+			}
+		} else {
+			n, err = io.Copy(bb, mp)
+		}
+		mp.Close()
+
+		p.Size = int(n)
+		p.Value = bb.Bytes()
+		if !p.IsFile() && rd.isField(p.Name) {
+			p.Value = []byte(Redacted)
+		}
+		parts = append(parts, p)
+
+		if err != nil {
+			return parts, err
+		}
+	}
+}
+
+// summarizeParts returns the text summary of the parts
+//
+//	--- [1] name="user"
+//	u1
+//	--- [2] name="file" filename="a.png" content-type="image/png" size=12345
+//	(12345 bytes omitted)
+func summarizeParts(parts []*Part, perr error) []byte {
+	bb := &bytes.Buffer{}
+
+	for i, p := range parts {
+		bb.WriteString(fmt.Sprintf("--- [%d] name=%q", i+1, p.Name))
+		if p.IsFile() {
+			bb.WriteString(fmt.Sprintf(" filename=%q", p.Filename))
+		}
+		if p.ContentType != "" {
+			bb.WriteString(fmt.Sprintf(" content-type=%q", p.ContentType))
+		}
+		bb.WriteString(fmt.Sprintf(" size=%d", p.Size))
+		bb.WriteString(eol)
+
+		if len(p.Value) > 0 {
+			bb.Write(p.Value)
+			bb.WriteString(eol)
+		}
+		if p.IsFile() && p.Truncated() > 0 {
+			bb.WriteString(fmt.Sprintf("(%d bytes omitted)", p.Truncated()))
+			bb.WriteString(eol)
+		}
+	}
+
+	if perr != nil {
+		bb.WriteString(fmt.Sprintf("--- (malformed multipart body: %v)", perr))
+		bb.WriteString(eol)
+	}
+
+	return bytes.TrimSuffix(bb.Bytes(), []byte(eol))
+}
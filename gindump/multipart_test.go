@@ -0,0 +1,165 @@
+package gindump
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newMultipartRequest(t *testing.T) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("user", "u1")         //nolint: errcheck
+	mw.WriteField("password", "secret") //nolint: errcheck
+	fw, _ := mw.CreateFormFile("file", "a.bin")
+	fw.Write([]byte("FILE-CONTENT-" + strings.Repeat("x", 100))) //nolint: errcheck
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestHttpDumpMultipart(t *testing.T) {
+	router := gin.New()
+
+	buffer := new(bytes.Buffer)
+	dumper := New(buffer)
+	router.Use(dumper.Handler())
+
+	router.POST("/upload", func(c *gin.Context) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		f, _ := fh.Open()
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		c.String(http.StatusOK, "%s %d", c.PostForm("user"), len(data))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newMultipartRequest(t))
+	if w.Body.String() != "u1 113" {
+		t.Errorf("body = %q, the handler can not read the multipart body", w.Body.String())
+	}
+
+	dump := buffer.String()
+	if strings.Contains(dump, "FILE-CONTENT") || strings.Contains(dump, "secret") {
+		t.Errorf("http dump contains the file content or password: %q", dump)
+	}
+	assertContains(t, "POST /upload", dump,
+		"--- [1] name=\"user\" size=2\r\nu1\r\n",
+		"--- [2] name=\"password\" size=6\r\n******\r\n",
+		"--- [3] name=\"file\" filename=\"a.bin\" content-type=\"application/octet-stream\" size=113\r\n(113 bytes omitted)\r\n",
+	)
+}
+
+func TestHttpDumpMultipartMaxFileSize(t *testing.T) {
+	rb := NewRingBuffer(1)
+	dumper := New(nil)
+	dumper.SetRecorder(rb)
+	dumper.SetMaxFileSize(12)
+
+	router := gin.New()
+	router.Use(dumper.Handler())
+	router.POST("/upload", func(c *gin.Context) {})
+	router.ServeHTTP(httptest.NewRecorder(), newMultipartRequest(t))
+
+	rec := rb.Get(rb.Records()[0].ID)
+	if len(rec.RequestParts) != 3 {
+		t.Fatalf("len(RequestParts) = %d, want %d", len(rec.RequestParts), 3)
+	}
+
+	p := rec.RequestParts[2]
+	if string(p.Value) != "FILE-CONTENT" || p.Truncated() != 101 {
+		t.Errorf("Part = %q, %d", p.Value, p.Truncated())
+	}
+	if p := rec.RequestParts[1]; string(p.Value) != Redacted || p.Size != 6 || p.Truncated() != 0 {
+		t.Errorf("Part = %q, %d, %d", p.Value, p.Size, p.Truncated())
+	}
+	if jr := newJSONRecord(rec); jr.Request.Parts[1].ValueTruncated != 0 {
+		t.Errorf("json valueTruncated = %d", jr.Request.Parts[1].ValueTruncated)
+	}
+	assertContains(t, "summary", string(rec.RequestBody), "FILE-CONTENT\r\n(101 bytes omitted)")
+
+	assertContains(t, "curl", curlCommand(rec),
+		`--form-string 'user=u1'`,
+		`--form-string 'password=******'`,
+		`-F 'file=@a.bin;type=application/octet-stream'`,
+	)
+	if strings.Contains(curlCommand(rec), "Content-Type") {
+		t.Errorf("curl command contains Content-Type header: %q", curlCommand(rec))
+	}
+
+	assertContains(t, "go", goSnippet(rec),
+		`mw.WriteField("user", "u1")`,
+		`fw, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Disposition": {"form-data; name=\"file\"; filename=\"a.bin\""}, "Content-Type": {"application/octet-stream"}})`,
+		`fw.Write([]byte("FILE-CONTENT")) // 101 bytes omitted`,
+		`req.Header.Set("Content-Type", mw.FormDataContentType())`,
+	)
+}
+
+func TestHttpDumpMultipartMaxBodySize(t *testing.T) {
+	rb := NewRingBuffer(1)
+	dumper := New(nil)
+	dumper.SetRecorder(rb)
+	dumper.SetMaxBodySize(300)
+
+	router := gin.New()
+	router.Use(dumper.Handler())
+	router.POST("/upload", func(c *gin.Context) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%d", fh.Size)
+	})
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("user", "u1") //nolint: errcheck
+	fw, _ := mw.CreateFormFile("file", "a.bin")
+	fw.Write([]byte(strings.Repeat("x", 10000))) //nolint: errcheck
+	mw.Close()
+
+	size := body.Len()
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "10000" {
+		t.Errorf("body = %q, the handler can not read the multipart body", w.Body.String())
+	}
+
+	rec := rb.Records()[0]
+	if len(rec.RequestParts) != 2 || rec.RequestTruncated != size-300 {
+		t.Errorf("len(RequestParts) = %d, RequestTruncated = %d", len(rec.RequestParts), rec.RequestTruncated)
+	}
+	if strings.Contains(string(rec.RequestBody), "malformed") {
+		t.Errorf("RequestBody = %q", rec.RequestBody)
+	}
+}
+
+func TestParseMultipartMalformed(t *testing.T) {
+	req := newMultipartRequest(t)
+	body, _ := io.ReadAll(req.Body)
+
+	parts, err := parseMultipart(req.Header.Get("Content-Type"), body[:len(body)-50], newRedactor(), 0)
+	if err == nil {
+		t.Error("parseMultipart() = nil error")
+	}
+	if len(parts) != 3 {
+		t.Errorf("len(parts) = %d, want %d", len(parts), 3)
+	}
+	assertContains(t, "summary", string(summarizeParts(parts, err)), "--- (malformed multipart body: ")
+}
@@ -0,0 +1,154 @@
+package gindump
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yffrankwang/ginx/str"
+)
+
+// Record a dumped http request/response exchange.
+// The sensitive data of the record are redacted and the bodies are truncated by the max body size.
+type Record struct {
+	ID       string    // the unique id of the exchange
+	ParentID string    // the id of the inbound exchange which sends the outbound request
+	Seq      uint64    // the sequence number of the exchange
+	Start    time.Time // the time when the request is received
+	End      time.Time // the time when the response is completed (or the connection is hijacked)
+
+	Method           string      // the request method
+	Scheme           string      // the request scheme ("http" or "https")
+	URL              string      // the request URI (or the absolute URL of a client request)
+	Route            string      // the matched gin route path (e.g. "/users/:id")
+	Proto            string      // the request protocol
+	Host             string      // the request host
+	RemoteAddr       string      // the remote address of the request
+	RequestHeader    http.Header // the request header
+	RequestBody      []byte      // the request body (the summary of the multipart/form-data body)
+	RequestParts     []*Part     // the parts of the multipart/form-data request body
+	RequestTruncated int         // the truncated size of the request body
+
+	Status            int         // the response status code
+	ResponseHeader    http.Header // the response header
+	ResponseTrailer   http.Header // the response trailer
+	ResponseBody      []byte      // the response body
+	ResponseTruncated int         // the truncated size of the response body
+	Hijacked          bool        // the connection is hijacked
+	Websocket         bool        // the request is a websocket upgrade request
+	Error             string      // the error of the outbound request
+}
+
+// Duration returns the elapsed time of the exchange
+func (rec *Record) Duration() time.Duration {
+	return rec.End.Sub(rec.Start)
+}
+
+// AbsoluteURL returns the absolute URL of the request
+func (rec *Record) AbsoluteURL() string {
+	if strings.HasPrefix(rec.URL, "http://") || strings.HasPrefix(rec.URL, "https://") {
+		return rec.URL
+	}
+	return rec.Scheme + "://" + rec.Host + rec.URL
+}
+
+// Path returns the path of the request URL
+func (rec *Record) Path() string {
+	u := str.SubstrBeforeByte(rec.URL, '?')
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+		if j := strings.IndexByte(u, '/'); j >= 0 {
+			return u[j:]
+		}
+		return "/"
+	}
+	return u
+}
+
+// setRequest set the request part of the record.
+// The body is the (at most max body size) head of the request body,
+// more is true if the body is the truncated head of the request body.
+// The truncated size is computed from the raw body by the caller, not from the redacted body.
+func (rec *Record) setRequest(req *http.Request, body []byte, more bool, rd *redactor, maxFile int) {
+	rec.Method = req.Method
+	rec.Scheme = "http"
+	if req.TLS != nil {
+		rec.Scheme = "https"
+	}
+	if req.URL != nil && req.URL.Scheme != "" {
+		rec.Scheme = req.URL.Scheme
+	}
+	rec.URL = rd.url(req)
+	rec.Proto = req.Proto
+	rec.Host = req.Host
+	if rec.Host == "" && req.URL != nil {
+		rec.Host = req.URL.Host
+	}
+	rec.RemoteAddr = req.RemoteAddr
+	rec.RequestHeader = rd.header(req.Header)
+
+	ct := req.Header.Get("Content-Type")
+	if isMultipartType(ct) && len(body) > 0 {
+		parts, err := parseMultipart(ct, body, rd, maxFile)
+		if more && errors.Is(err, io.ErrUnexpectedEOF) {
+			// the truncated body, the truncated size is reported by RequestTruncated
+			err = nil
+		}
+		if len(parts) > 0 {
+			rec.RequestParts = parts
+			rec.RequestBody = summarizeParts(parts, err)
+		} else {
+			rec.RequestBody = []byte(fmt.Sprintf("(malformed multipart body (%d bytes): %v)", len(body), err))
+		}
+	} else {
+		rec.RequestBody = rd.body(ct, body)
+	}
+	rec.Websocket = isWebsocket(req)
+}
+
+// setResponse set the response part of the record.
+// The body should be truncated on capture, size is the actual size of the body.
+func (rec *Record) setResponse(status int, header, trailer http.Header, body []byte, size int, rd *redactor) {
+	rec.Status = status
+	rec.ResponseHeader = rd.header(header)
+	if len(trailer) > 0 {
+		rec.ResponseTrailer = rd.header(trailer)
+	}
+	rec.ResponseBody = rd.body(header.Get("Content-Type"), body)
+	if size > len(body) {
+		rec.ResponseTruncated = size - len(body)
+	}
+}
+
+// splitTrailer split the response header h to header and trailer.
+// The trailer values are the declared "Trailer" values and the values with the http.TrailerPrefix key.
+func splitTrailer(h http.Header) (header, trailer http.Header) {
+	header = h.Clone()
+	trailer = http.Header{}
+
+	for _, vs := range h["Trailer"] {
+		for _, k := range strings.Split(vs, ",") {
+			k = http.CanonicalHeaderKey(strings.TrimSpace(k))
+			if v, ok := h[k]; ok {
+				trailer[k] = v
+				delete(header, k)
+			}
+		}
+	}
+
+	for k, v := range h {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			trailer[http.CanonicalHeaderKey(k[len(http.TrailerPrefix):])] = v
+			delete(header, k)
+		}
+	}
+	return
+}
+
+func isWebsocket(req *http.Request) bool {
+	return str.ContainsFold(req.Header.Get("Connection"), "upgrade") &&
+		strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
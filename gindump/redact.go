@@ -0,0 +1,516 @@
+package gindump
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/yffrankwang/ginx/str"
+)
+
+// Redacted the mask string of a redacted value
+const Redacted = "******"
+
+// DefaultRedactHeaders default redacted header names
+var DefaultRedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// DefaultRedactCookies default redacted cookie names ("*" matches any cookie)
+var DefaultRedactCookies = []string{"*"}
+
+// DefaultRedactFields default redacted form field (and query parameter) names
+var DefaultRedactFields = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"client_secret",
+}
+
+// DefaultRedactJSONPaths default redacted JSON paths
+var DefaultRedactJSONPaths = []string{
+	"$..password",
+	"$..passwd",
+	"$..secret",
+	"$..token",
+	"$..access_token",
+	"$..refresh_token",
+	"$..client_secret",
+}
+
+// redactor redact the sensitive data of the request and response
+type redactor struct {
+	headers   map[string]bool // canonical header names
+	cookies   map[string]bool // cookie names, "*" matches any cookie
+	fields    map[string]bool // lower case form field names
+	jsonPaths []jsonPath
+
+	triggerHeader string // canonical trigger header name
+	triggerParam  string // trigger query parameter name
+}
+
+func newRedactor() *redactor {
+	rd := &redactor{}
+	rd.setHeaders(DefaultRedactHeaders...)
+	rd.setCookies(DefaultRedactCookies...)
+	rd.setFields(DefaultRedactFields...)
+	rd.setJSONPaths(DefaultRedactJSONPaths...)
+	return rd
+}
+
+func (rd *redactor) setHeaders(names ...string) {
+	hs := make(map[string]bool, len(names))
+	for _, n := range names {
+		hs[http.CanonicalHeaderKey(n)] = true
+	}
+	rd.headers = hs
+}
+
+func (rd *redactor) setCookies(names ...string) {
+	cs := make(map[string]bool, len(names))
+	for _, n := range names {
+		cs[n] = true
+	}
+	rd.cookies = cs
+}
+
+func (rd *redactor) setFields(names ...string) {
+	fs := make(map[string]bool, len(names))
+	for _, n := range names {
+		fs[strings.ToLower(n)] = true
+	}
+	rd.fields = fs
+}
+
+func (rd *redactor) setJSONPaths(paths ...string) {
+	jps := make([]jsonPath, len(paths))
+	for i, p := range paths {
+		jp, err := parseJSONPath(p)
+		if err != nil {
+			panic(err)
+		}
+		jps[i] = jp
+	}
+	rd.jsonPaths = jps
+}
+
+func (rd *redactor) isCookie(name string) bool {
+	return rd.cookies["*"] || rd.cookies[name]
+}
+
+func (rd *redactor) isField(name string) bool {
+	return rd.fields[strings.ToLower(name)]
+}
+
+// header returns a redacted copy of the header h
+func (rd *redactor) header(h http.Header) http.Header {
+	h2 := h.Clone()
+	for k, vs := range h2 {
+		if rd.headers[k] || (k == rd.triggerHeader && k != "") {
+			for i := range vs {
+				vs[i] = Redacted
+			}
+			continue
+		}
+
+		switch k {
+		case "Cookie":
+			for i, v := range vs {
+				vs[i] = rd.cookie(v)
+			}
+		case "Set-Cookie":
+			for i, v := range vs {
+				vs[i] = rd.setCookie(v)
+			}
+		}
+	}
+	return h2
+}
+
+// cookie redact the cookie values of the "Cookie" header value
+func (rd *redactor) cookie(v string) string {
+	if len(rd.cookies) == 0 {
+		return v
+	}
+
+	cs := strings.Split(v, ";")
+	for i, c := range cs {
+		n := strings.TrimSpace(str.SubstrBeforeByte(c, '='))
+		if n != "" && str.ContainsByte(c, '=') && rd.isCookie(n) {
+			cs[i] = str.SubstrBeforeByte(c, '=') + "=" + Redacted
+		}
+	}
+	return strings.Join(cs, ";")
+}
+
+// setCookie redact the cookie value of the "Set-Cookie" header value
+func (rd *redactor) setCookie(v string) string {
+	if len(rd.cookies) == 0 {
+		return v
+	}
+
+	p := strings.IndexByte(v, '=')
+	if p < 0 {
+		return v
+	}
+
+	n := strings.TrimSpace(v[:p])
+	if !rd.isCookie(n) {
+		return v
+	}
+
+	e := strings.IndexByte(v[p:], ';')
+	if e < 0 {
+		return v[:p+1] + Redacted
+	}
+	return v[:p+1] + Redacted + v[p+e:]
+}
+
+// query redact the field values of the url encoded query string
+func (rd *redactor) query(q string) string {
+	if (len(rd.fields) == 0 && rd.triggerParam == "") || q == "" {
+		return q
+	}
+
+	ps := strings.Split(q, "&")
+	for i, p := range ps {
+		k := str.SubstrBeforeByte(p, '=')
+		if k == p {
+			continue
+		}
+		if uk, err := url.QueryUnescape(k); err == nil && (rd.isField(uk) || (uk == rd.triggerParam && uk != "")) {
+			ps[i] = k + "=" + Redacted
+		}
+	}
+	return strings.Join(ps, "&")
+}
+
+// body redact the body by the content type
+func (rd *redactor) body(ct string, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	switch {
+	case isFormType(ct):
+		if len(rd.fields) > 0 {
+			return []byte(rd.query(string(body)))
+		}
+	case isJSONType(ct):
+		if len(rd.jsonPaths) > 0 {
+			return redactJSON(body, rd.jsonPaths)
+		}
+	}
+	return body
+}
+
+// url returns the redacted request URI (or the URL of a client request)
+func (rd *redactor) url(req *http.Request) string {
+	u := req.RequestURI
+	if u == "" && req.URL != nil {
+		u = req.URL.String()
+		if ui := req.URL.User; ui != nil {
+			// redact the password (or the token as the user name) of the userinfo
+			ru := *req.URL
+			ru.User = nil
+			u = ru.String()
+
+			rui := Redacted
+			if _, ok := ui.Password(); ok {
+				rui = url.User(ui.Username()).String() + ":" + Redacted
+			}
+			if i := strings.Index(u, "://"); i >= 0 {
+				u = u[:i+3] + rui + "@" + u[i+3:]
+			}
+		}
+	}
+	if i := strings.IndexByte(u, '?'); i >= 0 {
+		u = u[:i+1] + rd.query(u[i+1:])
+	}
+	return u
+}
+
+func mediaType(ct string) string {
+	return strings.ToLower(strings.TrimSpace(str.SubstrBeforeByte(ct, ';')))
+}
+
+func isFormType(ct string) bool {
+	return mediaType(ct) == "application/x-www-form-urlencoded"
+}
+
+func isJSONType(ct string) bool {
+	mt := mediaType(ct)
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+//-------------------------------------------------
+// JSON path
+
+// jsonStep a step of the json path
+type jsonStep struct {
+	name  string // object member name
+	index int    // array index, -1: not a array index
+	any   bool   // wildcard "*"
+	deep  bool   // recursive descent ".."
+}
+
+func (js jsonStep) match(seg interface{}) bool {
+	switch v := seg.(type) {
+	case string:
+		return js.any || (js.index < 0 && js.name == v)
+	case int:
+		return js.any || js.index == v
+	}
+	return false
+}
+
+// jsonPath a parsed simple json path.
+// Supported syntax: "$", ".name", "..name", ".*", "['name']", "[0]", "[*]".
+type jsonPath []jsonStep
+
+func parseJSONPath(p string) (jsonPath, error) {
+	if !strings.HasPrefix(p, "$") {
+		return nil, fmt.Errorf("gindump: invalid json path %q", p)
+	}
+
+	jp := jsonPath{}
+	s := p[1:]
+	for s != "" {
+		step := jsonStep{index: -1}
+		switch {
+		case strings.HasPrefix(s, ".."):
+			step.deep = true
+			s = s[2:]
+		case s[0] == '.':
+			s = s[1:]
+		case s[0] == '[':
+		default:
+			return nil, fmt.Errorf("gindump: invalid json path %q", p)
+		}
+
+		if s == "" {
+			return nil, fmt.Errorf("gindump: invalid json path %q", p)
+		}
+
+		if s[0] == '[' {
+			e := strings.IndexByte(s, ']')
+			if e < 0 {
+				return nil, fmt.Errorf("gindump: invalid json path %q", p)
+			}
+			b := s[1:e]
+			s = s[e+1:]
+			switch {
+			case b == "*":
+				step.any = true
+			case len(b) > 1 && (b[0] == '\'' || b[0] == '"') && b[len(b)-1] == b[0]:
+				step.name = b[1 : len(b)-1]
+			default:
+				i, err := strconv.Atoi(b)
+				if err != nil || i < 0 {
+					return nil, fmt.Errorf("gindump: invalid json path %q", p)
+				}
+				step.index = i
+			}
+		} else {
+			e := strings.IndexAny(s, ".[")
+			if e < 0 {
+				e = len(s)
+			}
+			step.name = s[:e]
+			s = s[e:]
+			if step.name == "" {
+				return nil, fmt.Errorf("gindump: invalid json path %q", p)
+			}
+			if step.name == "*" {
+				step.any = true
+			}
+		}
+		jp = append(jp, step)
+	}
+
+	if len(jp) == 0 {
+		return nil, fmt.Errorf("gindump: invalid json path %q", p)
+	}
+	return jp, nil
+}
+
+// match reports whether the json path matches the location segs
+func (jp jsonPath) match(segs []interface{}) bool {
+	if len(jp) == 0 {
+		return len(segs) == 0
+	}
+	if len(segs) == 0 {
+		return false
+	}
+
+	step := jp[0]
+	if step.match(segs[0]) && jp[1:].match(segs[1:]) {
+		return true
+	}
+	if step.deep {
+		return jp.match(segs[1:])
+	}
+	return false
+}
+
+// redactJSON replace the values of the matched json paths with Redacted.
+// The original data is returned if nothing matched.
+// For a truncated json, the redacted part before the unexpected EOF is returned.
+// For a malformed json, the whole data is replaced with a placeholder, because
+// the sensitive values can not be located.
+func redactJSON(data []byte, jps []jsonPath) []byte {
+	jr := &jsonRedactor{
+		dec:   json.NewDecoder(bytes.NewReader(data)),
+		paths: jps,
+	}
+	jr.dec.UseNumber()
+
+	err := jr.value(nil)
+	if err == nil {
+		// trailing data after the json value
+		if _, err = jr.dec.Token(); errors.Is(err, io.EOF) {
+			err = nil
+		} else if err == nil {
+			err = errors.New("gindump: trailing data after json value")
+		}
+	}
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return jr.buf.Bytes()
+		}
+		return []byte(fmt.Sprintf("(unparsable JSON body, %d bytes redacted)", len(data)))
+	}
+	if !jr.redacted {
+		return data
+	}
+	return jr.buf.Bytes()
+}
+
+type jsonRedactor struct {
+	dec      *json.Decoder
+	buf      bytes.Buffer
+	paths    []jsonPath
+	redacted bool
+}
+
+func (jr *jsonRedactor) matched(segs []interface{}) bool {
+	if len(segs) == 0 {
+		return false
+	}
+	for _, jp := range jr.paths {
+		if jp.match(segs) {
+			return true
+		}
+	}
+	return false
+}
+
+func (jr *jsonRedactor) value(segs []interface{}) error {
+	t, err := jr.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if jr.matched(segs) {
+		jr.redacted = true
+		jr.writeString(Redacted)
+		if d, ok := t.(json.Delim); ok {
+			return jr.skip(d)
+		}
+		return nil
+	}
+
+	switch v := t.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			jr.buf.WriteByte('{')
+			for i := 0; jr.dec.More(); i++ {
+				kt, err := jr.dec.Token()
+				if err != nil {
+					return err
+				}
+				k, ok := kt.(string)
+				if !ok {
+					return fmt.Errorf("gindump: invalid json key %v", kt)
+				}
+				if i > 0 {
+					jr.buf.WriteByte(',')
+				}
+				jr.writeString(k)
+				jr.buf.WriteByte(':')
+				if err := jr.value(append(segs, k)); err != nil {
+					return err
+				}
+			}
+			if _, err := jr.dec.Token(); err != nil {
+				return err
+			}
+			jr.buf.WriteByte('}')
+		case '[':
+			jr.buf.WriteByte('[')
+			for i := 0; jr.dec.More(); i++ {
+				if i > 0 {
+					jr.buf.WriteByte(',')
+				}
+				if err := jr.value(append(segs, i)); err != nil {
+					return err
+				}
+			}
+			if _, err := jr.dec.Token(); err != nil {
+				return err
+			}
+			jr.buf.WriteByte(']')
+		}
+	case string:
+		jr.writeString(v)
+	case json.Number:
+		jr.buf.WriteString(v.String())
+	case bool:
+		jr.buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		jr.buf.WriteString("null")
+	}
+	return nil
+}
+
+// skip skip the tokens until the end of the object or array started with d
+func (jr *jsonRedactor) skip(d json.Delim) error {
+	if d != '{' && d != '[' {
+		return nil
+	}
+
+	for depth := 1; depth > 0; {
+		t, err := jr.dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := t.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+func (jr *jsonRedactor) writeString(s string) {
+	enc := json.NewEncoder(&jr.buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) //nolint: errcheck
+	jr.buf.Truncate(jr.buf.Len() - 1)
+}
@@ -0,0 +1,83 @@
+package gindump
+
+import (
+	"sync"
+)
+
+// Recorder records the dumped exchanges
+type Recorder interface {
+	// Record record the dumped exchange.
+	// The record should not be modified.
+	Record(rec *Record)
+}
+
+// RingBuffer a Recorder which keeps the last N dumped exchanges in memory
+type RingBuffer struct {
+	mutex   sync.RWMutex
+	records []*Record
+	head    int // the index of the next record
+	count   int // the count of the records
+}
+
+// NewRingBuffer create a ring buffer which keeps the last size exchanges
+func NewRingBuffer(size int) *RingBuffer {
+	if size < 1 {
+		size = 1
+	}
+	return &RingBuffer{records: make([]*Record, size)}
+}
+
+// Record implements the Recorder interface
+func (rb *RingBuffer) Record(rec *Record) {
+	rb.mutex.Lock()
+	rb.records[rb.head] = rec
+	rb.head = (rb.head + 1) % len(rb.records)
+	if rb.count < len(rb.records) {
+		rb.count++
+	}
+	rb.mutex.Unlock()
+}
+
+// Len returns the count of the records
+func (rb *RingBuffer) Len() int {
+	rb.mutex.RLock()
+	defer rb.mutex.RUnlock()
+
+	return rb.count
+}
+
+// Records returns the records (newest first)
+func (rb *RingBuffer) Records() []*Record {
+	rb.mutex.RLock()
+	defer rb.mutex.RUnlock()
+
+	size := len(rb.records)
+	recs := make([]*Record, rb.count)
+	for i := 0; i < rb.count; i++ {
+		recs[i] = rb.records[(rb.head-1-i+size)%size]
+	}
+	return recs
+}
+
+// Get returns the record of the id, returns nil if not found
+func (rb *RingBuffer) Get(id string) *Record {
+	rb.mutex.RLock()
+	defer rb.mutex.RUnlock()
+
+	for _, rec := range rb.records {
+		if rec != nil && rec.ID == id {
+			return rec
+		}
+	}
+	return nil
+}
+
+// Clear remove all the records
+func (rb *RingBuffer) Clear() {
+	rb.mutex.Lock()
+	for i := range rb.records {
+		rb.records[i] = nil
+	}
+	rb.head, rb.count = 0, 0
+	rb.mutex.Unlock()
+}
@@ -0,0 +1,128 @@
+package gindump
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRingBuffer(t *testing.T) {
+	rb := NewRingBuffer(3)
+	for i := 1; i <= 5; i++ {
+		rb.Record(&Record{ID: strconv.Itoa(i)})
+	}
+
+	if rb.Len() != 3 {
+		t.Errorf("Len() = %d, want %d", rb.Len(), 3)
+	}
+
+	recs := rb.Records()
+	ids := []string{}
+	for _, rec := range recs {
+		ids = append(ids, rec.ID)
+	}
+	if a, w := strings.Join(ids, ","), "5,4,3"; a != w {
+		t.Errorf("Records() = %v, want %v", a, w)
+	}
+
+	if rb.Get("2") != nil {
+		t.Error(`Get("2") != nil`)
+	}
+	if rb.Get("4") == nil {
+		t.Error(`Get("4") == nil`)
+	}
+
+	rb.Clear()
+	if rb.Len() != 0 || len(rb.Records()) != 0 {
+		t.Errorf("Len() = %d after Clear()", rb.Len())
+	}
+}
+
+func TestInspector(t *testing.T) {
+	rb := NewRingBuffer(10)
+	dumper := New(nil)
+	dumper.SetRecorder(rb)
+
+	router := gin.New()
+	rb.Mount(router.Group("/_dump"))
+
+	api := router.Group("/api", dumper.Handler())
+	api.POST("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "it's "+c.Param("id"))
+	})
+	api.GET("/missing", func(c *gin.Context) {
+		c.String(http.StatusNotFound, "not found")
+	})
+
+	req := httptest.NewRequest("POST", "/api/users/1", strings.NewReader("name=it's me"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/missing", nil))
+
+	if rb.Len() != 2 {
+		t.Fatalf("Len() = %d, want %d", rb.Len(), 2)
+	}
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// list json
+	w := get("/_dump/?status=4xx", "application/json")
+	jss := []*jsonSummary{}
+	if err := json.Unmarshal(w.Body.Bytes(), &jss); err != nil {
+		t.Fatalf("json.Unmarshal(%q) = %v", w.Body.String(), err)
+	}
+	if len(jss) != 1 || jss[0].URL != "/api/missing" || jss[0].Status != 404 {
+		t.Errorf("list = %q", w.Body.String())
+	}
+
+	// list html
+	w = get("/_dump/?path=/users", "")
+	assertContains(t, "list", w.Body.String(), "/api/users/1")
+	if strings.Contains(w.Body.String(), "/api/missing") {
+		t.Errorf("list html contains filtered record /api/missing")
+	}
+
+	// detail
+	id := rb.Records()[1].ID
+	w = get("/_dump/"+id, "")
+	assertContains(t, "detail", w.Body.String(), "POST /api/users/1 HTTP/1.1", "it&#39;s 1", "Copy as curl")
+
+	w = get("/_dump/"+id+"?format=json", "")
+	jr := &jsonRecord{}
+	if err := json.Unmarshal(w.Body.Bytes(), jr); err != nil || jr.ID != id || jr.Response.Body != "it's 1" {
+		t.Errorf("detail json = %q", w.Body.String())
+	}
+
+	// curl
+	w = get("/_dump/"+id+"/curl", "")
+	assertContains(t, "curl", w.Body.String(),
+		`curl -X 'POST' 'http://example.com/api/users/1'`,
+		`-H 'Content-Type: application/x-www-form-urlencoded'`,
+		`--data-binary 'name=it'\''s me'`,
+	)
+
+	// not found
+	if w = get("/_dump/unknown", ""); w.Code != http.StatusNotFound {
+		t.Errorf("detail unknown = %d", w.Code)
+	}
+
+	// clear
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/_dump/", nil))
+	if w.Code != http.StatusNoContent || rb.Len() != 0 {
+		t.Errorf("clear = %d, Len() = %d", w.Code, rb.Len())
+	}
+}
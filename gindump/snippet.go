@@ -0,0 +1,176 @@
+package gindump
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// curlCommand returns the curl command line of the record's request.
+// The redacted values are kept as Redacted in the command line.
+// The method is specified by -X if it is not the one implied by curl (e.g. a GET request with a body).
+func curlCommand(rec *Record) string {
+	bb := &bytes.Buffer{}
+
+	hasBody := len(rec.RequestParts) > 0 || len(rec.RequestBody) > 0
+
+	bb.WriteString("curl")
+	switch {
+	case (rec.Method == "" || rec.Method == "GET") && !hasBody:
+	case rec.Method == "HEAD" && !hasBody:
+		bb.WriteString(" -I")
+	default:
+		method := rec.Method
+		if method == "" {
+			method = "GET"
+		}
+		bb.WriteString(" -X ")
+		bb.WriteString(shellQuote(method))
+	}
+	bb.WriteString(" ")
+	bb.WriteString(shellQuote(rec.AbsoluteURL()))
+
+	for _, k := range sortedKeys(rec.RequestHeader) {
+		if k == "Content-Length" || k == "Host" || (k == "Content-Type" && len(rec.RequestParts) > 0) {
+			continue
+		}
+		for _, v := range rec.RequestHeader[k] {
+			bb.WriteString(" \\\n  -H ")
+			bb.WriteString(shellQuote(k + ": " + v))
+		}
+	}
+
+	if len(rec.RequestParts) > 0 {
+		// the file content is not dumped, use the file name as the local file path
+		for _, p := range rec.RequestParts {
+			if p.IsFile() {
+				a := p.Name + "=@" + p.Filename
+				if p.ContentType != "" {
+					a += ";type=" + p.ContentType
+				}
+				bb.WriteString(" \\\n  -F ")
+				bb.WriteString(shellQuote(a))
+			} else {
+				bb.WriteString(" \\\n  --form-string ")
+				bb.WriteString(shellQuoteBytes([]byte(p.Name + "=" + string(p.Value))))
+			}
+		}
+	} else if len(rec.RequestBody) > 0 {
+		bb.WriteString(" \\\n  --data-binary ")
+		bb.WriteString(shellQuoteBytes(rec.RequestBody))
+	}
+	if rec.RequestTruncated > 0 {
+		bb.WriteString(fmt.Sprintf(" # %d bytes truncated", rec.RequestTruncated))
+	}
+	return bb.String()
+}
+
+// goSnippet returns the Go code snippet which creates the record's request by httptest.NewRequest.
+// The redacted values are kept as Redacted in the code.
+func goSnippet(rec *Record) string {
+	bb := &bytes.Buffer{}
+
+	body := "nil"
+	if len(rec.RequestParts) > 0 {
+		body = "body"
+		bb.WriteString("body := &bytes.Buffer{}\n")
+		bb.WriteString("mw := multipart.NewWriter(body)\n")
+		assign := ":="
+		for _, p := range rec.RequestParts {
+			if p.IsFile() {
+				bb.WriteString(fmt.Sprintf("fw, _ %s mw.CreatePart(%s)\n", assign, partHeader(p)))
+				assign = "="
+				bb.WriteString(fmt.Sprintf("fw.Write([]byte(%s))", strconv.Quote(string(p.Value))))
+				if p.Truncated() > 0 {
+					bb.WriteString(fmt.Sprintf(" // %d bytes omitted", p.Truncated()))
+				}
+				bb.WriteString("\n")
+			} else {
+				bb.WriteString(fmt.Sprintf("mw.WriteField(%q, %s)\n", p.Name, strconv.Quote(string(p.Value))))
+			}
+		}
+		bb.WriteString("mw.Close()\n")
+	} else if len(rec.RequestBody) > 0 {
+		body = "strings.NewReader(" + strconv.Quote(string(rec.RequestBody)) + ")"
+	}
+	bb.WriteString(fmt.Sprintf("req := httptest.NewRequest(%q, %q, %s)", rec.Method, rec.AbsoluteURL(), body))
+	if rec.RequestTruncated > 0 {
+		bb.WriteString(fmt.Sprintf(" // %d bytes truncated", rec.RequestTruncated))
+	}
+	bb.WriteString("\n")
+	if len(rec.RequestParts) > 0 {
+		bb.WriteString("req.Header.Set(\"Content-Type\", mw.FormDataContentType())\n")
+	}
+
+	for _, k := range sortedKeys(rec.RequestHeader) {
+		if k == "Content-Length" || k == "Host" || (k == "Content-Type" && len(rec.RequestParts) > 0) {
+			continue
+		}
+		for _, v := range rec.RequestHeader[k] {
+			bb.WriteString(fmt.Sprintf("req.Header.Add(%q, %q)\n", k, v))
+		}
+	}
+	return bb.String()
+}
+
+// partHeader returns the Go code of the textproto.MIMEHeader of the file part
+func partHeader(p *Part) string {
+	cd := fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(p.Name), quoteEscaper.Replace(p.Filename))
+	ct := p.ContentType
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	return fmt.Sprintf(`textproto.MIMEHeader{"Content-Disposition": {%s}, "Content-Type": {%s}}`, strconv.Quote(cd), strconv.Quote(ct))
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func sortedKeys(h map[string][]string) []string {
+	ks := make([]string, 0, len(h))
+	for k := range h {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+// shellQuote quote the string s for POSIX shell by single quotes
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellQuoteBytes quote the data for shell.
+// The ANSI-C quoting $'...' is used if the data contains non-printable characters.
+func shellQuoteBytes(data []byte) string {
+	printable := utf8.Valid(data)
+	if printable {
+		for _, c := range data {
+			if c < 0x20 && c != '\t' && c != '\n' && c != '\r' || c == 0x7f {
+				printable = false
+				break
+			}
+		}
+	}
+	if printable {
+		return shellQuote(string(data))
+	}
+
+	bb := &bytes.Buffer{}
+	bb.WriteString("$'")
+	for _, c := range data {
+		switch {
+		case c == '\'' || c == '\\':
+			bb.WriteByte('\\')
+			bb.WriteByte(c)
+		case c >= 0x20 && c < 0x7f:
+			bb.WriteByte(c)
+		default:
+			bb.WriteString(fmt.Sprintf(`\x%02x`, c))
+		}
+	}
+	bb.WriteString("'")
+	return bb.String()
+}
@@ -0,0 +1,114 @@
+package gindump
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestShellQuote(t *testing.T) {
+	cs := []struct {
+		s string
+		w string
+	}{
+		{"abc", `'abc'`},
+		{"it's", `'it'\''s'`},
+		{"a\nb", "'a\nb'"},
+		{"a\x00b'\\", `$'a\x00b\'\\'`},
+		{"\xff\xfe", `$'\xff\xfe'`},
+	}
+
+	for i, c := range cs {
+		if a := shellQuoteBytes([]byte(c.s)); a != c.w {
+			t.Errorf("[%d] shellQuoteBytes(%q) = %s, want %s", i, c.s, a, c.w)
+		}
+	}
+}
+
+func TestCurlCommand(t *testing.T) {
+	rec := &Record{
+		Method:        "HEAD",
+		Scheme:        "https",
+		Host:          "example.com",
+		URL:           "/a?b=1",
+		RequestHeader: http.Header{"Accept": {"*/*"}, "Content-Length": {"0"}},
+	}
+	if a, w := curlCommand(rec), "curl -I 'https://example.com/a?b=1' \\\n  -H 'Accept: */*'"; a != w {
+		t.Errorf("curlCommand() = %q, want %q", a, w)
+	}
+
+	// GET with a truncated body
+	rec = &Record{
+		Method:           "GET",
+		Scheme:           "http",
+		Host:             "example.com",
+		URL:              "/a",
+		RequestBody:      []byte("abc"),
+		RequestTruncated: 10,
+	}
+	if a, w := curlCommand(rec), "curl -X 'GET' 'http://example.com/a' \\\n  --data-binary 'abc' # 10 bytes truncated"; a != w {
+		t.Errorf("curlCommand() = %q, want %q", a, w)
+	}
+}
+
+func TestGoSnippet(t *testing.T) {
+	rec := &Record{
+		Method:        "PUT",
+		Scheme:        "http",
+		URL:           "http://localhost:8080/a",
+		RequestHeader: http.Header{"X-B": {"2"}, "X-A": {"1", "\"3\""}},
+		RequestBody:   []byte("line1\nline2\xff"),
+	}
+
+	w := `req := httptest.NewRequest("PUT", "http://localhost:8080/a", strings.NewReader("line1\nline2\xff"))
+req.Header.Add("X-A", "1")
+req.Header.Add("X-A", "\"3\"")
+req.Header.Add("X-B", "2")
+`
+	if a := goSnippet(rec); a != w {
+		t.Errorf("goSnippet() = %q, want %q", a, w)
+	}
+
+	rec.RequestHeader = nil
+	rec.RequestTruncated = 10
+	w = `req := httptest.NewRequest("PUT", "http://localhost:8080/a", strings.NewReader("line1\nline2\xff")) // 10 bytes truncated
+`
+	if a := goSnippet(rec); a != w {
+		t.Errorf("goSnippet() = %q, want %q", a, w)
+	}
+}
+
+func TestHttpDumpCurlAndGo(t *testing.T) {
+	for _, f := range []Formatter{CurlFormatter, GoFormatter} {
+		router := gin.New()
+
+		buffer := new(bytes.Buffer)
+		dumper := New(buffer)
+		dumper.SetFormatter(f)
+		router.Use(dumper.Handler())
+
+		router.POST("/example", func(c *gin.Context) {
+			c.String(http.StatusOK, "response-body")
+		})
+
+		req := httptest.NewRequest("POST", "/example", strings.NewReader(`{"a":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		dump := buffer.String()
+		if strings.Contains(dump, "response-body") {
+			t.Errorf("%T dump contains the response body: %q", f, dump)
+		}
+
+		switch f {
+		case CurlFormatter:
+			assertContains(t, "curl", dump, "# ", " #1 ", `curl -X 'POST' 'http://example.com/example'`, `--data-binary '{"a":1}'`)
+		case GoFormatter:
+			assertContains(t, "go", dump, "// ", " #1 ", `req := httptest.NewRequest("POST", "http://example.com/example", strings.NewReader("{\"a\":1}"))`)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package gindump
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// Transport returns a http.RoundTripper which dumps the outbound requests and responses
+// by the same options (output, formatter, recorder, redaction, max body size) of the dumper.
+// If rt is nil, http.DefaultTransport is used.
+// If the outbound request's context is a *gin.Context or the request context of a
+// dumped inbound request (c.Request.Context()), the outbound dump is tagged with the
+// inbound exchange id as the parent id.
+//
+//	client := &http.Client{Transport: dumper.Transport(nil)}
+//	req, _ := http.NewRequestWithContext(c, "GET", "http://upstream/", nil)
+//	res, err := client.Do(req)
+func (d *Dumper) Transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &transport{dumper: d, rt: rt}
+}
+
+// transport a http.RoundTripper which dumps the outbound request and response
+type transport struct {
+	dumper *Dumper
+	rt     http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	d := t.dumper
+	if !d.enabled() {
+		return t.rt.RoundTrip(req)
+	}
+
+	pid := getID(req.Context())
+	if d.trigger != nil && pid == "" {
+		return t.rt.RoundTrip(req)
+	}
+
+	ex := d.newExchange(pid)
+
+	// RoundTrip should not modify the request
+	r2 := req.Clone(req.Context())
+	ex.dumpRequest(r2)
+
+	res, err := t.rt.RoundTrip(r2)
+	if err != nil {
+		ex.finish(func(rec *Record) {
+			rec.Error = err.Error()
+		})
+		return res, err
+	}
+
+	res.Body = &dumpBody{ReadCloser: res.Body, ex: ex, res: res}
+	return res, nil
+}
+
+// dumpBody a response body which captures the read data,
+// the exchange is dumped when the body is read to EOF or closed.
+type dumpBody struct {
+	io.ReadCloser
+
+	ex   *exchange
+	res  *http.Response
+	bb   bytes.Buffer
+	size int
+}
+
+func (db *dumpBody) Read(p []byte) (int, error) {
+	n, err := db.ReadCloser.Read(p)
+	if n > 0 {
+		db.size += n
+		capture(&db.bb, p[:n], db.ex.maxBody)
+	}
+	if err == io.EOF { //nolint: errorlint
+		db.ex.finish(db.fill)
+	}
+	return n, err
+}
+
+func (db *dumpBody) Close() error {
+	err := db.ReadCloser.Close()
+	db.ex.finish(db.fill)
+	return err
+}
+
+// fill set the response part of the record
+func (db *dumpBody) fill(rec *Record) {
+	res := db.res
+	rec.setResponse(res.StatusCode, res.Header, res.Trailer, db.bb.Bytes(), db.size, db.ex.redactor)
+}
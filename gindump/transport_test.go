@@ -0,0 +1,98 @@
+package gindump
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTransport(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"echo":"` + string(body) + `","token":"upstream-token"}`)) //nolint: errcheck
+	}))
+	defer upstream.Close()
+
+	buffer := new(bytes.Buffer)
+	dumper := New(buffer)
+	dumper.Atomic(true)
+
+	client := &http.Client{Transport: dumper.Transport(nil)}
+
+	router := gin.New()
+	router.Use(dumper.Handler())
+	router.GET("/proxy", func(c *gin.Context) {
+		req, _ := http.NewRequestWithContext(c, "POST", upstream.URL+"/api?token=query-token", strings.NewReader("hello"))
+		req.Header.Set("Authorization", "Bearer secret")
+		res, err := client.Do(req)
+		if err != nil {
+			c.String(http.StatusBadGateway, err.Error())
+			return
+		}
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(res.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/proxy", nil))
+
+	if !strings.Contains(w.Body.String(), `"echo":"hello"`) || !strings.Contains(w.Body.String(), "upstream-token") {
+		t.Fatalf("body = %q", w.Body.String())
+	}
+
+	dump := buffer.String()
+	if strings.Contains(dump, "query-token") || strings.Contains(dump, "Bearer secret") {
+		t.Errorf("http dump is not redacted: %q", dump)
+	}
+
+	// outbound first, inbound last (atomic)
+	i := strings.LastIndex(dump, ">>>>>>>>\r\nGET /proxy HTTP/1.1")
+	if i < 0 {
+		t.Fatalf("inbound dump not found: %q", dump)
+	}
+	ss := strings.Fields(dump[strings.LastIndex(dump[:i], ">>>>>>>> "):])
+	inboundID := ss[3]
+
+	assertContains(t, "POST /api", dump,
+		"parent="+inboundID,
+		"POST "+upstream.URL+"/api?token=****** HTTP/1.1",
+		"Authorization: ******",
+		"\r\n\r\nhello\r\n",
+		"HTTP/1.1 200 OK",
+		`{"echo":"hello","token":"******"}`,
+	)
+}
+
+type errorTransport struct{}
+
+func (errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestTransportError(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	dumper := New(buffer)
+	dumper.SetMaxBodySize(3)
+
+	client := &http.Client{Transport: dumper.Transport(errorTransport{})}
+
+	req, _ := http.NewRequest("PUT", "http://localhost/error", strings.NewReader("abcdef"))
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("client.Do() = nil error")
+	}
+
+	assertContains(t, "PUT /error", buffer.String(),
+		"PUT http://localhost/error HTTP/1.1",
+		"abc\r\n... (3 bytes truncated)",
+		"[error: connection refused]",
+	)
+}
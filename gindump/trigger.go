@@ -0,0 +1,74 @@
+package gindump
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TriggerHeader default trigger header name
+	TriggerHeader = "X-Gindump-Token"
+
+	// TriggerParam default trigger query parameter name
+	TriggerParam = "__gindump"
+)
+
+// trigger only the requests carrying the valid token are dumped
+type trigger struct {
+	header string
+	param  string
+	secret []byte
+}
+
+// NewTriggerToken create a HMAC signed trigger token which expires at the expires time.
+// The token format is "{expires unix time}.{hex hmac-sha256(secret, expires unix time)}".
+func NewTriggerToken(secret string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + signToken([]byte(secret), exp)
+}
+
+func signToken(secret []byte, exp string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(exp)) //nolint: errcheck
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// match returns true if the request carries a valid token
+func (t *trigger) match(req *http.Request) bool {
+	if t.header != "" {
+		if v := req.Header.Get(t.header); v != "" && t.verify(v) {
+			return true
+		}
+	}
+	if t.param != "" && req.URL != nil {
+		if v := req.URL.Query().Get(t.param); v != "" && t.verify(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify returns true if the token equals to the secret or is a valid HMAC signed token
+func (t *trigger) verify(token string) bool {
+	if subtle.ConstantTimeCompare([]byte(token), t.secret) == 1 {
+		return true
+	}
+
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return false
+	}
+
+	exp, sig := token[:i], token[i+1:]
+	ts, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > ts {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(signToken(t.secret, exp)))
+}
@@ -0,0 +1,144 @@
+package gindump
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTriggerVerify(t *testing.T) {
+	tg := &trigger{secret: []byte("secret")}
+
+	cs := []struct {
+		token string
+		want  bool
+	}{
+		{"secret", true},
+		{"secret2", false},
+		{"", false},
+		{NewTriggerToken("secret", time.Now().Add(time.Minute)), true},
+		{NewTriggerToken("secret", time.Now().Add(-time.Minute)), false},
+		{NewTriggerToken("other", time.Now().Add(time.Minute)), false},
+		{"9999999999.abcdef", false},
+		{"abc.def", false},
+	}
+
+	for i, c := range cs {
+		if a := tg.verify(c.token); a != c.want {
+			t.Errorf("[%d] verify(%q) = %v, want %v", i, c.token, a, c.want)
+		}
+	}
+}
+
+func TestHttpDumpTrigger(t *testing.T) {
+	rb := NewRingBuffer(10)
+	dumper := New(nil)
+	dumper.SetRecorder(rb)
+	dumper.SetTrigger(TriggerHeader, TriggerParam, "secret")
+
+	router := gin.New()
+	router.Use(dumper.Handler())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Query(TriggerParam))
+	})
+
+	token := NewTriggerToken("secret", time.Now().Add(time.Hour))
+	cs := []struct {
+		path   string
+		header string
+		dump   bool
+	}{
+		{"/", "", false},
+		{"/?a=1", "wrong", false},
+		{"/?" + TriggerParam + "=wrong", "", false},
+		{"/?a=1&" + TriggerParam + "=secret", "", true},
+		{"/", "secret", true},
+		{"/", token, true},
+		{"/?" + TriggerParam + "=" + NewTriggerToken("secret", time.Now().Add(-time.Hour)), "", false},
+	}
+
+	for i, c := range cs {
+		rb.Clear()
+
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.header != "" {
+			req.Header.Set(TriggerHeader, c.header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("[%d] status = %d", i, w.Code)
+		}
+		if a := rb.Len() == 1; a != c.dump {
+			t.Errorf("[%d] %s dumped = %v, want %v", i, c.path, a, c.dump)
+			continue
+		}
+		if !c.dump {
+			continue
+		}
+
+		rec := rb.Records()[0]
+		if strings.Contains(rec.URL, "secret") || strings.Contains(rec.URL, token) {
+			t.Errorf("[%d] trigger param is not redacted: %s", i, rec.URL)
+		}
+		if v := rec.RequestHeader.Get(TriggerHeader); v != "" && v != Redacted {
+			t.Errorf("[%d] trigger header is not redacted: %s", i, v)
+		}
+	}
+
+	// disable trigger
+	dumper.SetTrigger(TriggerHeader, TriggerParam, "")
+	rb.Clear()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if rb.Len() != 1 {
+		t.Errorf("dumped = %d, want 1", rb.Len())
+	}
+}
+
+func TestTransportTrigger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok")) //nolint: errcheck
+	}))
+	defer ts.Close()
+
+	rb := NewRingBuffer(10)
+	dumper := New(nil)
+	dumper.SetRecorder(rb)
+	dumper.SetTrigger(TriggerHeader, "", "secret")
+
+	client := &http.Client{Transport: dumper.Transport(nil)}
+
+	router := gin.New()
+	router.Use(dumper.Handler())
+	router.GET("/", func(c *gin.Context) {
+		req, _ := http.NewRequestWithContext(c.Request.Context(), "GET", ts.URL, nil)
+		res, err := client.Do(req)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		res.Body.Close()
+		c.String(http.StatusOK, "ok")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if rb.Len() != 0 {
+		t.Errorf("untriggered dumped = %d, want 0", rb.Len())
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(TriggerHeader, "secret")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if rb.Len() != 2 {
+		t.Fatalf("triggered dumped = %d, want 2", rb.Len())
+	}
+	recs := rb.Records()
+	if recs[1].ParentID != recs[0].ID {
+		t.Errorf("parent id = %q, want %q", recs[1].ParentID, recs[0].ID)
+	}
+}
@@ -0,0 +1,73 @@
+package gindump
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yffrankwang/ginx/str"
+)
+
+// dumpWriter a gin.ResponseWriter which captures the response body
+type dumpWriter struct {
+	gin.ResponseWriter
+
+	ex       *exchange
+	bb       bytes.Buffer
+	hijacked bool
+}
+
+// implements http.ResponseWriter
+func (dw *dumpWriter) Write(data []byte) (int, error) {
+	dw.capture(data)
+	return dw.ResponseWriter.Write(data)
+}
+
+// implements gin.ResponseWriter
+func (dw *dumpWriter) WriteString(s string) (int, error) {
+	dw.capture(str.UnsafeBytes(s))
+	return dw.ResponseWriter.WriteString(s)
+}
+
+// ReadFrom implements the io.ReaderFrom interface.
+func (dw *dumpWriter) ReadFrom(r io.Reader) (int64, error) {
+	// hide the ReadFrom method to avoid recursion
+	return io.Copy(struct{ io.Writer }{dw}, r)
+}
+
+// Flush implements the http.Flush interface.
+// The flushed data is written to the client immediately and kept in the dump buffer.
+func (dw *dumpWriter) Flush() {
+	dw.ResponseWriter.Flush()
+}
+
+// Hijack implements the http.Hijacker interface.
+// The response of the hijacked connection (websocket) is dumped immediately,
+// because the handler may not return until the connection is closed.
+func (dw *dumpWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := dw.ResponseWriter.Hijack()
+	if err == nil {
+		dw.hijacked = true
+		dw.ex.finish(dw.fill)
+	}
+	return conn, rw, err
+}
+
+func (dw *dumpWriter) capture(data []byte) {
+	if !dw.hijacked {
+		capture(&dw.bb, data, dw.ex.maxBody)
+	}
+}
+
+// fill set the response part of the record
+func (dw *dumpWriter) fill(rec *Record) {
+	if dw.hijacked {
+		rec.Hijacked = true
+		return
+	}
+
+	header, trailer := splitTrailer(dw.ResponseWriter.Header())
+	rec.setResponse(dw.ResponseWriter.Status(), header, trailer, dw.bb.Bytes(), dw.ResponseWriter.Size(), dw.ex.redactor)
+}
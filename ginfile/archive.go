@@ -0,0 +1,328 @@
+package ginfile
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// archive formats
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarGz = "tar.gz"
+)
+
+var errArchiveTooLarge = errors.New("ginfile: archive too large")
+
+// Archiver streams a directory of the http.FileSystem as a zip or tar.gz archive.
+// The archive is written directly to the response, no temporary file is created and
+// the files are copied one by one, so the memory usage does not grow with the archive size.
+// Use http.FS(fsys) to archive a fs.FS.
+//
+// The archive format and the file name are decided by the last element of the URL path
+// ("*.zip", "*.tar.gz" or "*.tgz"). If the route has a "*path" parameter, the directory
+// is the parameter without the archive extension, otherwise the root directory is archived.
+//
+//	ar := ginfile.NewArchiver(http.Dir("./share"))
+//	router.GET("/share.zip", ar.Handler())       // "/share.zip" -> "./share"
+//	router.GET("/download/*path", ar.Handler())  // "/download/docs.tar.gz" -> "./share/docs"
+//
+// Like the static file handler, the dotfiles are excluded by default.
+type Archiver struct {
+	hfs      http.FileSystem
+	dotfiles bool
+	includes []string
+	excludes []string
+	maxSize  int64
+}
+
+// NewArchiver create a directory archive handler of the http.FileSystem
+func NewArchiver(hfs http.FileSystem) *Archiver {
+	return &Archiver{hfs: hfs}
+}
+
+// SetAllowDotfiles set whether to archive the dotfiles.
+// Default: false
+func (ar *Archiver) SetAllowDotfiles(allow bool) {
+	ar.dotfiles = allow
+}
+
+// SetIncludes set the glob patterns (see path.Match) of the files to be archived.
+// If the pattern contains "/", the pattern is matched with the full file name of the http.FileSystem,
+// otherwise the pattern is matched with the base name of the file.
+// If the include patterns are set, only the matched files are archived and
+// the directory entries are omitted from the archive.
+// Default: empty (all files are archived)
+// Panic if the pattern is malformed.
+func (ar *Archiver) SetIncludes(patterns ...string) {
+	checkGlobs(patterns)
+	ar.includes = patterns
+}
+
+// SetExcludes set the glob patterns (see path.Match) of the files and directories to be excluded.
+// The patterns are matched in the same way as the Deny option.
+// Default: empty
+// Panic if the pattern is malformed.
+func (ar *Archiver) SetExcludes(patterns ...string) {
+	checkGlobs(patterns)
+	ar.excludes = patterns
+}
+
+// SetMaxSize set the max total size of the archived files (before compression).
+// The request is responded as "413 Request Entity Too Large" if the directory is too large.
+// Default: 0 (unlimited)
+func (ar *Archiver) SetMaxSize(maxSize int64) {
+	ar.maxSize = maxSize
+}
+
+// Handler returns the gin.HandlerFunc
+func (ar *Archiver) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ar.handle(c)
+	}
+}
+
+// handle process gin request
+func (ar *Archiver) handle(c *gin.Context) {
+	filename := path.Base(c.Request.URL.Path)
+	format, ext := archiveFormat(filename)
+	if format == "" {
+		http.Error(c.Writer, statusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	dir := "/"
+	if p := c.Param("path"); p != "" {
+		dir = path.Clean("/" + strings.TrimSuffix(p, ext))
+	}
+
+	ents, err := ar.walk(dir)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errArchiveTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		} else if errors.Is(err, fs.ErrNotExist) {
+			status = http.StatusNotFound
+		}
+		http.Error(c.Writer, statusText(status), status)
+		return
+	}
+
+	if format == ArchiveZip {
+		c.Header("Content-Type", "application/zip")
+	} else {
+		c.Header("Content-Type", "application/gzip")
+	}
+	c.Header("Content-Disposition", ContentDisposition("attachment", filename))
+	c.Status(http.StatusOK)
+
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+
+	if err := ar.write(c.Writer, format, ents); err != nil {
+		// the response is already started, the truncated archive is broken
+		c.Error(err) //nolint: errcheck
+		c.Abort()
+	}
+}
+
+// Write write the archive of the directory dir in the format (ArchiveZip or ArchiveTarGz) to w.
+func (ar *Archiver) Write(w io.Writer, format, dir string) error {
+	if format != ArchiveZip && format != ArchiveTarGz {
+		return fmt.Errorf("ginfile: unknown archive format %q", format)
+	}
+
+	ents, err := ar.walk(path.Clean("/" + dir))
+	if err != nil {
+		return err
+	}
+	return ar.write(w, format, ents)
+}
+
+// archiveFormat returns the archive format and the extension of the file name
+func archiveFormat(filename string) (string, string) {
+	switch {
+	case strings.HasSuffix(filename, ".zip"):
+		return ArchiveZip, ".zip"
+	case strings.HasSuffix(filename, ".tar.gz"):
+		return ArchiveTarGz, ".tar.gz"
+	case strings.HasSuffix(filename, ".tgz"):
+		return ArchiveTarGz, ".tgz"
+	}
+	return "", ""
+}
+
+// archiveEntry a file or directory to be archived
+type archiveEntry struct {
+	name string // the file name of the http.FileSystem
+	rel  string // the relative name in the archive
+	fi   fs.FileInfo
+}
+
+// walk returns the entries of the directory dir (sorted by name).
+// Only the file infos are collected, the contents are read by write.
+func (ar *Archiver) walk(dir string) ([]*archiveEntry, error) {
+	pfs := &policyFS{hfs: ar.hfs, dotfiles: ar.dotfiles, denies: ar.excludes}
+
+	var ents []*archiveEntry
+	var total int64
+
+	var walk func(name, rel string) error
+	walk = func(name, rel string) error {
+		f, err := pfs.Open(name)
+		if err != nil {
+			return err
+		}
+		fis, err := f.Readdir(-1)
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+		for _, fi := range fis {
+			en := &archiveEntry{name: path.Join(name, fi.Name()), rel: rel + fi.Name(), fi: fi}
+			if fi.IsDir() {
+				if len(ar.includes) == 0 {
+					en.rel += "/"
+					ents = append(ents, en)
+				}
+				if err := walk(en.name, rel+fi.Name()+"/"); err != nil {
+					return err
+				}
+				continue
+			}
+			if !fi.Mode().IsRegular() || !ar.included(en.name) {
+				continue
+			}
+
+			total += fi.Size()
+			if ar.maxSize > 0 && total > ar.maxSize {
+				return errArchiveTooLarge
+			}
+			ents = append(ents, en)
+		}
+		return nil
+	}
+
+	f, err := pfs.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: dir, Err: fs.ErrNotExist}
+	}
+
+	if err := walk(dir, ""); err != nil {
+		return nil, err
+	}
+	return ents, nil
+}
+
+// included returns true if the file name matches the include patterns
+func (ar *Archiver) included(name string) bool {
+	if len(ar.includes) == 0 {
+		return true
+	}
+	for _, p := range ar.includes {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// write write the entries to w.
+// Exactly the walked size of each file is copied, so the max size is not exceeded
+// even if the file is modified after walk.
+func (ar *Archiver) write(w io.Writer, format string, ents []*archiveEntry) error {
+	if format == ArchiveZip {
+		return ar.writeZip(w, ents)
+	}
+	return ar.writeTarGz(w, ents)
+}
+
+func (ar *Archiver) writeZip(w io.Writer, ents []*archiveEntry) error {
+	zw := zip.NewWriter(w)
+
+	for _, en := range ents {
+		fh, err := zip.FileInfoHeader(en.fi)
+		if err != nil {
+			return err
+		}
+		fh.Name = en.rel
+		if en.fi.IsDir() {
+			fh.Method = zip.Store
+			if _, err := zw.CreateHeader(fh); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fh.Method = zip.Deflate
+		zf, err := zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		if err := ar.copyFile(zf, en); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (ar *Archiver) writeTarGz(w io.Writer, ents []*archiveEntry) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, en := range ents {
+		th, err := tar.FileInfoHeader(en.fi, "")
+		if err != nil {
+			return err
+		}
+		th.Name = en.rel
+		th.Uid, th.Gid, th.Uname, th.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(th); err != nil {
+			return err
+		}
+		if en.fi.IsDir() {
+			continue
+		}
+		if err := ar.copyFile(tw, en); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// copyFile copy the walked size of the file content to w
+func (ar *Archiver) copyFile(w io.Writer, en *archiveEntry) error {
+	f, err := ar.hfs.Open(en.name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.CopyN(w, f, en.fi.Size())
+	return err
+}
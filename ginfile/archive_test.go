@@ -0,0 +1,197 @@
+package ginfile
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func writeArchiveTestFiles(t *testing.T) string {
+	dir := writeTestFiles(t, map[string][]byte{
+		"a.txt":           []byte("a.txt"),
+		"b.pdf":           []byte("b.pdf"),
+		".env":            []byte("SECRET=1"),
+		"sub/c.txt":       []byte("sub/c.txt"),
+		"sub/d.bak":       []byte("sub/d.bak"),
+		"node_modules/x":  []byte("x"),
+		"docs/api/e.txt":  []byte("docs/api/e.txt"),
+		"docs/api/f.pdf":  []byte("docs/api/f.pdf"),
+		"docs/.git/HEAD":  []byte("HEAD"),
+		"docs/readme.txt": []byte("readme"),
+	})
+
+	mt := time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, "a.txt"), mt, mt); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func readZip(t *testing.T, data []byte) (map[string]string, map[string]time.Time) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, mtimes := map[string]string{}, map[string]time.Time{}
+	for _, zf := range zr.File {
+		f, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, _ := io.ReadAll(f)
+		f.Close()
+		files[zf.Name] = string(bs)
+		mtimes[zf.Name] = zf.Modified
+	}
+	return files, mtimes
+}
+
+func readTarGz(t *testing.T, data []byte) map[string]string {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	tr := tar.NewReader(gr)
+	for {
+		th, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, _ := io.ReadAll(tr)
+		files[th.Name] = string(bs)
+	}
+	return files
+}
+
+func TestArchiverZip(t *testing.T) {
+	dir := writeArchiveTestFiles(t)
+
+	ar := NewArchiver(http.Dir(dir))
+	ar.SetExcludes("*.bak", "node_modules")
+
+	r := gin.New()
+	r.GET("/all.zip", ar.Handler())
+	r.GET("/download/*path", ar.Handler())
+
+	w := testGetCode(t, r, "/all.zip", http.StatusOK)
+	if a := w.Header().Get("Content-Type"); a != "application/zip" {
+		t.Errorf("Content-Type = %q", a)
+	}
+	if a := w.Header().Get("Content-Disposition"); a != `attachment; filename="all.zip"` {
+		t.Errorf("Content-Disposition = %q", a)
+	}
+
+	files, mtimes := readZip(t, w.Body.Bytes())
+	want := map[string]string{
+		"a.txt":           "a.txt",
+		"b.pdf":           "b.pdf",
+		"docs/":           "",
+		"docs/api/":       "",
+		"docs/api/e.txt":  "docs/api/e.txt",
+		"docs/api/f.pdf":  "docs/api/f.pdf",
+		"docs/readme.txt": "readme",
+		"sub/":            "",
+		"sub/c.txt":       "sub/c.txt",
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("zip files = %v, want %v", files, want)
+	}
+	if mt := mtimes["a.txt"]; !mt.Equal(time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC)) {
+		t.Errorf("zip a.txt modified = %v", mt)
+	}
+
+	w = testGetCode(t, r, "/download/docs/api.zip", http.StatusOK)
+	files, _ = readZip(t, w.Body.Bytes())
+	want = map[string]string{
+		"e.txt": "docs/api/e.txt",
+		"f.pdf": "docs/api/f.pdf",
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("zip files = %v, want %v", files, want)
+	}
+
+	testGetCode(t, r, "/download/none.zip", http.StatusNotFound)
+	testGetCode(t, r, "/download/a.txt.zip", http.StatusNotFound)
+	testGetCode(t, r, "/download/docs.rar", http.StatusNotFound)
+	testGetCode(t, r, "/download/.git.zip", http.StatusNotFound)
+	testGetCode(t, r, "/download/../../etc.zip", http.StatusNotFound)
+}
+
+func TestArchiverTarGz(t *testing.T) {
+	dir := writeArchiveTestFiles(t)
+
+	ar := NewArchiver(http.Dir(dir))
+	ar.SetIncludes("*.txt")
+	ar.SetAllowDotfiles(true)
+
+	r := gin.New()
+	r.GET("/download/*path", ar.Handler())
+
+	w := testGetCode(t, r, "/download/docs.tgz", http.StatusOK)
+	if a := w.Header().Get("Content-Type"); a != "application/gzip" {
+		t.Errorf("Content-Type = %q", a)
+	}
+
+	files := readTarGz(t, w.Body.Bytes())
+	want := map[string]string{
+		"api/e.txt":  "docs/api/e.txt",
+		"readme.txt": "readme",
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("tar files = %v, want %v", files, want)
+	}
+
+	// dotfiles allowed
+	ar.SetIncludes()
+	w = testGetCode(t, r, "/download/.tar.gz", http.StatusOK)
+	files = readTarGz(t, w.Body.Bytes())
+	if files[".env"] != "SECRET=1" || files["docs/.git/HEAD"] != "HEAD" {
+		t.Errorf("tar files = %v", files)
+	}
+}
+
+func TestArchiverMaxSize(t *testing.T) {
+	dir := writeArchiveTestFiles(t)
+
+	ar := NewArchiver(http.Dir(dir))
+	ar.SetMaxSize(20)
+
+	r := gin.New()
+	r.GET("/download/*path", ar.Handler())
+
+	testGetCode(t, r, "/download/sub.zip", http.StatusOK)
+	testGetCode(t, r, "/download/docs.zip", http.StatusRequestEntityTooLarge)
+}
+
+func TestArchiverWrite(t *testing.T) {
+	ar := NewArchiver(http.FS(testdata))
+
+	bb := &bytes.Buffer{}
+	if err := ar.Write(bb, ArchiveZip, "/testdata"); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := readZip(t, bb.Bytes())
+	if len(files) == 0 {
+		t.Error("zip files is empty")
+	}
+
+	if err := ar.Write(bb, "rar", "/testdata"); err == nil {
+		t.Error("unknown format error expected")
+	}
+}
@@ -0,0 +1,159 @@
+package ginfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yffrankwang/ginx/ginhtml"
+)
+
+// Immutable1Year Cache-Control: public, max-age=31536000, immutable
+const Immutable1Year = "public, max-age=31536000, immutable"
+
+// Assets the fingerprinted static assets.
+// The file "app.js" is fingerprinted as "app.{hash}.js" by the content hash,
+// the fingerprinted URL is served with the immutable Cache-Control header,
+// so the URL is changed (cache busting) when the content of the file is changed.
+//
+//	assets, err := ginfile.NewAssets(os.DirFS("./static"))
+//	assets.Mount(router.Group("/static"), "/")
+//	assets.URL("app.js") // "/static/app.3f9a1c2b.js"
+//
+//	engine := ginhtml.NewEngine()
+//	engine.Funcs(assets.FuncMap(myFuncs)) // merged with the other template functions
+//	// {{asset "app.js"}} in the html template
+type Assets struct {
+	fsys   fs.FS
+	prefix string            // the URL prefix of the mounted assets
+	names  map[string]string // logical name -> fingerprinted name
+	files  map[string]string // fingerprinted name -> logical name
+}
+
+// NewAssets walk the file system fsys and compute the content hash of the files
+func NewAssets(fsys fs.FS) (*Assets, error) {
+	as := &Assets{
+		fsys:  fsys,
+		names: make(map[string]string),
+		files: make(map[string]string),
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		hash, err := hashFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		fp := fingerprint(name, hash)
+		as.names[name] = fp
+		as.files[fp] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+// Mount registers the routes in order to serve the assets.
+// The fingerprinted file (e.g. "app.3f9a1c2b.js") is served with the Immutable1Year Cache-Control header,
+// the original file (e.g. "app.js") is served with the "no-cache" Cache-Control header.
+func (as *Assets) Mount(g *gin.RouterGroup, relativePath string, opts ...Option) {
+	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
+		panic("URL parameters can not be used when serving a static folder")
+	}
+
+	as.prefix = strings.TrimSuffix(path.Join(g.BasePath(), relativePath), "/")
+
+	fh := newFileHandler(http.FS(as.fsys), "", opts...)
+	handler := func(c *gin.Context) {
+		name := strings.TrimPrefix(path.Clean("/"+c.Param("path")), "/")
+		if ln, ok := as.files[name]; ok {
+			c.Header("Cache-Control", Immutable1Year)
+			name = ln
+		} else {
+			c.Header("Cache-Control", "no-cache")
+		}
+		fh.serveFile(c.Writer, fh.request(c), "/"+name, false)
+	}
+
+	urlPattern := path.Join(relativePath, "/*path")
+
+	g.GET(urlPattern, handler)
+	g.HEAD(urlPattern, handler)
+}
+
+// Lookup returns the fingerprinted name of the logical asset name (e.g. "js/app.js" -> "js/app.3f9a1c2b.js").
+// Returns false if the asset is not found.
+func (as *Assets) Lookup(name string) (string, bool) {
+	fp, ok := as.names[strings.TrimPrefix(path.Clean("/"+name), "/")]
+	return fp, ok
+}
+
+// URL returns the fingerprinted URL of the logical asset name (e.g. "app.js" -> "/static/app.3f9a1c2b.js").
+// The URL of the logical name is returned if the asset is not found.
+func (as *Assets) URL(name string) string {
+	fp, ok := as.Lookup(name)
+	if !ok {
+		fp = strings.TrimPrefix(path.Clean("/"+name), "/")
+	}
+	return as.prefix + "/" + fp
+}
+
+// Manifest returns the manifest (logical name -> fingerprinted name) of the assets
+func (as *Assets) Manifest() map[string]string {
+	m := make(map[string]string, len(as.names))
+	for k, v := range as.names {
+		m[k] = v
+	}
+	return m
+}
+
+// FuncMap returns the template functions of the assets for ginhtml merged with the funcMaps.
+// Because ginhtml's Funcs() replaces the whole FuncMap of the engine instead of merging,
+// the other template functions should be passed to be merged into the returned FuncMap.
+//
+//	asset - returns the fingerprinted URL of the logical asset name: {{asset "app.js"}}
+func (as *Assets) FuncMap(funcMaps ...ginhtml.FuncMap) ginhtml.FuncMap {
+	fm := ginhtml.FuncMap{}
+	for _, m := range funcMaps {
+		for k, v := range m {
+			fm[k] = v
+		}
+	}
+	fm["asset"] = as.URL
+	return fm
+}
+
+// hashFile returns the hex content hash of the file
+func hashFile(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fingerprint returns the fingerprinted file name: "js/app.js" -> "js/app.{hash[:8]}.js"
+func fingerprint(name, hash string) string {
+	ext := path.Ext(name)
+	if ext == path.Base(name) {
+		ext = ""
+	}
+	return strings.TrimSuffix(name, ext) + "." + hash[:8] + ext
+}
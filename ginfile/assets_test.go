@@ -0,0 +1,110 @@
+package ginfile
+
+import (
+	"bytes"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yffrankwang/ginx/ginhtml"
+)
+
+func TestFingerprint(t *testing.T) {
+	cs := map[string]string{
+		"app.js":        "app.01234567.js",
+		"js/app.min.js": "js/app.min.01234567.js",
+		"LICENSE":       "LICENSE.01234567",
+		"d.x/README":    "d.x/README.01234567",
+	}
+	for name, want := range cs {
+		if a := fingerprint(name, "0123456789abcdef"); a != want {
+			t.Errorf("fingerprint(%q) = %q, want %q", name, a, want)
+		}
+	}
+}
+
+func TestAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":       {Data: []byte("app.js")},
+		"css/site.css": {Data: []byte("site.css")},
+	}
+
+	as, err := NewAssets(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	as.Mount(r.Group("/static"), "/")
+
+	url := as.URL("app.js")
+	if !regexp.MustCompile(`^/static/app\.[0-9a-f]{8}\.js$`).MatchString(url) {
+		t.Fatalf("URL(app.js) = %q", url)
+	}
+	if a := as.URL("/css/site.css"); !regexp.MustCompile(`^/static/css/site\.[0-9a-f]{8}\.css$`).MatchString(a) {
+		t.Errorf("URL(/css/site.css) = %q", a)
+	}
+	if a := as.URL("missing.js"); a != "/static/missing.js" {
+		t.Errorf("URL(missing.js) = %q", a)
+	}
+	if fp, ok := as.Lookup("css/site.css"); !ok || as.Manifest()["css/site.css"] != fp {
+		t.Errorf("Lookup(css/site.css) = %q, %v, manifest = %v", fp, ok, as.Manifest())
+	}
+
+	w := testGetCode(t, r, url, 200)
+	if w.Body.String() != "app.js" || w.Header().Get("Cache-Control") != Immutable1Year {
+		t.Errorf("%s = %q, Cache-Control = %q", url, w.Body.String(), w.Header().Get("Cache-Control"))
+	}
+	if ct := w.Header().Get("Content-Type"); !bytes.Contains([]byte(ct), []byte("javascript")) {
+		t.Errorf("%s Content-Type = %q", url, ct)
+	}
+
+	w = testGetCode(t, r, "/static/app.js", 200)
+	if w.Body.String() != "app.js" || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("/static/app.js = %q, Cache-Control = %q", w.Body.String(), w.Header().Get("Cache-Control"))
+	}
+
+	testGetCode(t, r, "/static/app.00000000.js", 404)
+
+	// changed content, changed URL
+	fsys["app.js"] = &fstest.MapFile{Data: []byte("app.js v2")}
+	as2, err := NewAssets(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if as2.names["app.js"] == as.names["app.js"] {
+		t.Errorf("fingerprint is not changed: %q", as2.names["app.js"])
+	}
+}
+
+func TestAssetsFuncMap(t *testing.T) {
+	as, err := NewAssets(fstest.MapFS{"app.js": {Data: []byte("app.js")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	as.Mount(&r.RouterGroup, "/assets")
+
+	ht := ginhtml.NewHTMLTemplates()
+	ht.Funcs(as.FuncMap(ginhtml.FuncMap{"upper": strings.ToUpper}))
+	err = ht.LoadFS(fstest.MapFS{
+		"index.html": {Data: []byte(`<script src="{{asset "app.js"}}"></script>{{upper "a"}}`)},
+	}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bb := &bytes.Buffer{}
+	if err := ht.Render(bb, "index", nil); err != nil {
+		t.Fatal(err)
+	}
+	if a, want := bb.String(), `<script src="`+as.URL("app.js")+`"></script>A`; a != want {
+		t.Errorf("rendered = %q, want %q", a, want)
+	}
+
+	testGetCode(t, r, as.URL("app.js"), http.StatusOK)
+}
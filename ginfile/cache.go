@@ -0,0 +1,167 @@
+package ginfile
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheRule a Cache-Control rule of the served files.
+// The Match function is called with the file name of the http.FileSystem (e.g. "/static/app.js").
+type CacheRule struct {
+	Match        func(name string) bool
+	CacheControl string
+}
+
+// CacheGlob create a cache rule which matches the file by the glob pattern (see path.Match).
+// If the pattern contains "/", the pattern is matched with the full file name,
+// otherwise the pattern is matched with the base name of the file.
+// Panic if the pattern is malformed.
+//
+//	ginfile.CacheGlob("*.html", "no-cache")
+func CacheGlob(pattern, cacheControl string) *CacheRule {
+	checkGlobs([]string{pattern})
+
+	return &CacheRule{
+		Match: func(name string) bool {
+			return matchGlob(pattern, name)
+		},
+		CacheControl: cacheControl,
+	}
+}
+
+// CacheRegexp create a cache rule which matches the file name by the regular expression.
+// Panic if the expression can not be compiled.
+//
+//	ginfile.CacheRegexp(`\.[0-9a-f]{8,}\.(js|css)$`, "public, max-age=31536000, immutable")
+func CacheRegexp(expr, cacheControl string) *CacheRule {
+	re := regexp.MustCompile(expr)
+	return &CacheRule{
+		Match:        re.MatchString,
+		CacheControl: cacheControl,
+	}
+}
+
+// CacheExt create a cache rule which matches the file by the file extensions (e.g. ".css", ".js").
+// The extensions are case insensitive.
+func CacheExt(cacheControl string, exts ...string) *CacheRule {
+	return &CacheRule{
+		Match: func(name string) bool {
+			ext := path.Ext(name)
+			for _, e := range exts {
+				if strings.EqualFold(e, ext) {
+					return true
+				}
+			}
+			return false
+		},
+		CacheControl: cacheControl,
+	}
+}
+
+// CacheRules set the Cache-Control rules of the static mount.
+// The first matched rule is applied, the cacheControl argument of the static mount is
+// applied if no rule is matched.
+//
+//	ginfile.StaticFS(g, "/static", "", hfs, "public, max-age=3600", ginfile.CacheRules(
+//		ginfile.CacheRegexp(`\.[0-9a-f]{8,}\.(js|css)$`, "public, max-age=31536000, immutable"),
+//		ginfile.CacheGlob("*.html", "no-cache"),
+//	))
+func CacheRules(rules ...*CacheRule) Option {
+	return func(fh *fileHandler) {
+		fh.cacheRules = rules
+	}
+}
+
+// CacheExpires set the "Expires" header by the "max-age" directive of the Cache-Control header
+// for the HTTP/1.0 caches.
+// The "Expires" header is set to the past time if the Cache-Control is "no-cache" or "no-store".
+func CacheExpires() Option {
+	return func(fh *fileHandler) {
+		fh.expires = true
+	}
+}
+
+// cacheControlOf returns the Cache-Control of the file name
+func (fh *fileHandler) cacheControlOf(name string) string {
+	for _, cr := range fh.cacheRules {
+		if cr.Match(name) {
+			return cr.CacheControl
+		}
+	}
+	return fh.cacheControl
+}
+
+// cacheWriter returns a http.ResponseWriter which sets the Cache-Control (and Expires) header of the file name
+// when the response status is 200, 206 or 304, the error responses (e.g. 412, 416) are not cached.
+func (fh *fileHandler) cacheWriter(w http.ResponseWriter, name string) http.ResponseWriter {
+	return &cacheWriter{ResponseWriter: w, fh: fh, name: name}
+}
+
+// cacheWriter set the cache headers on WriteHeader(statusCode int)
+type cacheWriter struct {
+	http.ResponseWriter
+	fh     *fileHandler
+	name   string
+	header bool
+}
+
+// WriteHeader set the cache headers when statusCode is 200, 206 or 304
+func (cw *cacheWriter) WriteHeader(statusCode int) {
+	if !cw.header {
+		cw.header = true
+		switch statusCode {
+		case http.StatusOK, http.StatusPartialContent, http.StatusNotModified:
+			cw.fh.setCacheHeaders(cw.ResponseWriter, cw.name)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write write the header 200 before the first write
+func (cw *cacheWriter) Write(data []byte) (int, error) {
+	if !cw.header {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(data)
+}
+
+// setCacheHeaders set the Cache-Control (and Expires) header of the file name.
+// A existing header will not be overwritten.
+func (fh *fileHandler) setCacheHeaders(w http.ResponseWriter, name string) {
+	h := w.Header()
+
+	cc := h.Get("Cache-Control")
+	if cc == "" {
+		cc = fh.cacheControlOf(name)
+		if cc == "" {
+			return
+		}
+		h.Set("Cache-Control", cc)
+	}
+
+	if fh.expires && h.Get("Expires") == "" {
+		if exp, ok := expiresOf(cc); ok {
+			h.Set("Expires", exp.UTC().Format(http.TimeFormat))
+		}
+	}
+}
+
+// expiresOf returns the expires time of the Cache-Control value
+func expiresOf(cacheControl string) (time.Time, bool) {
+	for _, d := range strings.Split(cacheControl, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		switch {
+		case d == "no-cache" || d == "no-store":
+			return time.Unix(0, 0), true
+		case strings.HasPrefix(d, "max-age="):
+			if n, err := strconv.ParseInt(d[8:], 10, 64); err == nil {
+				return time.Now().Add(time.Duration(n) * time.Second), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
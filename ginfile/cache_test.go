@@ -0,0 +1,131 @@
+package ginfile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCacheRules(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"index.html":           []byte("index.html"),
+		"app.3f9a1c2b.js":      []byte("app.3f9a1c2b.js"),
+		"app.js":               []byte("app.js"),
+		"style.CSS":            []byte("style.CSS"),
+		"docs/a.txt":           []byte("a.txt"),
+		"docs/private/b.txt":   []byte("b.txt"),
+		"images/logo.png.html": []byte("logo.png.html"),
+	})
+
+	const immutable = "public, max-age=31536000, immutable"
+
+	r := gin.New()
+	Static(&r.RouterGroup, "/", dir, "public, max-age=60", CacheRules(
+		CacheRegexp(`\.[0-9a-f]{8,}\.(js|css)$`, immutable),
+		CacheGlob("*.html", "no-cache"),
+		CacheGlob("/docs/private/*", "private"),
+		CacheExt("public, max-age=3600", ".css"),
+	), CacheExpires())
+
+	cs := []struct {
+		path    string
+		cache   string
+		expires time.Duration
+	}{
+		{"/", "no-cache", -1},
+		{"/app.3f9a1c2b.js", immutable, 31536000 * time.Second},
+		{"/app.js", "public, max-age=60", 60 * time.Second},
+		{"/style.CSS", "public, max-age=3600", time.Hour},
+		{"/docs/a.txt", "public, max-age=60", 60 * time.Second},
+		{"/docs/private/b.txt", "private", 0},
+		{"/images/logo.png.html", "no-cache", -1},
+	}
+
+	for i, c := range cs {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", c.path, nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("[%d] %s code = %d", i, c.path, w.Code)
+		}
+		if a := w.Header().Get("Cache-Control"); a != c.cache {
+			t.Errorf("[%d] %s Cache-Control = %q, want %q", i, c.path, a, c.cache)
+		}
+
+		exp := w.Header().Get("Expires")
+		switch {
+		case c.expires == 0:
+			if exp != "" {
+				t.Errorf("[%d] %s Expires = %q, want empty", i, c.path, exp)
+			}
+		case c.expires < 0:
+			if et, err := http.ParseTime(exp); err != nil || et.After(time.Now()) {
+				t.Errorf("[%d] %s Expires = %q, want past time", i, c.path, exp)
+			}
+		default:
+			et, err := http.ParseTime(exp)
+			if d := time.Until(et) - c.expires; err != nil || d > 2*time.Second || d < -2*time.Second {
+				t.Errorf("[%d] %s Expires = %q, want %v later", i, c.path, exp, c.expires)
+			}
+		}
+	}
+
+	// not found
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/missing.html", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get("Cache-Control") != "" {
+		t.Errorf("/missing.html = %d, Cache-Control = %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestCacheStatus(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"app.js": []byte("app.js"),
+	})
+
+	r := gin.New()
+	Static(&r.RouterGroup, "/", dir, "public, max-age=60", CacheExpires())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/app.js", nil))
+	lastModified := w.Header().Get("Last-Modified")
+
+	cs := []struct {
+		header string
+		value  string
+		code   int
+		cached bool
+	}{
+		{"Range", "bytes=0-1", http.StatusPartialContent, true},
+		{"If-Modified-Since", lastModified, http.StatusNotModified, true},
+		{"Range", "bytes=100-200", http.StatusRequestedRangeNotSatisfiable, false},
+		{"If-Unmodified-Since", "Mon, 02 Jan 2006 15:04:05 GMT", http.StatusPreconditionFailed, false},
+	}
+
+	for i, c := range cs {
+		req := httptest.NewRequest("GET", "/app.js", nil)
+		req.Header.Set(c.header, c.value)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("[%d] code = %d, want %d", i, w.Code, c.code)
+		}
+		cc, exp := w.Header().Get("Cache-Control"), w.Header().Get("Expires")
+		if c.cached != (cc != "") || c.cached != (exp != "") {
+			t.Errorf("[%d] %d Cache-Control = %q, Expires = %q", i, w.Code, cc, exp)
+		}
+	}
+}
+
+func TestCacheGlobInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("CacheGlob() does not panic")
+		}
+	}()
+	CacheGlob("[", "no-cache")
+}
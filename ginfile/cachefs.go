@@ -0,0 +1,347 @@
+package ginfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheFS a http.FileSystem which caches the regular files of the underlying file system in memory.
+// The cached files are evicted by the LRU (least recently used) order when the total size exceeds the max size.
+// The cached file holds the content, the modification time, the content hash ETag and
+// the gzip compressed content (see SetCompress), which are used by the static file handlers.
+// The directories and the files larger than the max file size are not cached.
+//
+//	cfs := ginfile.NewCacheFS(http.Dir("./public"), 64<<20, 1<<20)
+//	cfs.SetRevalidate(time.Second * 5)
+//	ginfile.StaticFS(g, "/", "", cfs, ginfile.Public1Year)
+type CacheFS struct {
+	hfs        http.FileSystem
+	maxSize    int64
+	maxFile    int64
+	ttl        time.Duration
+	revalidate time.Duration
+	compress   int // the minimum size of the compressed file (0: disabled)
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // the front is the most recently used
+	size    int64
+}
+
+// cacheEntry a cached file
+type cacheEntry struct {
+	name    string
+	info    fs.FileInfo
+	data    []byte
+	etag    string
+	gzdata  []byte
+	gzetag  string
+	loaded  time.Time
+	checked time.Time
+}
+
+// NewCacheFS create a in-memory caching file system of the hfs.
+// maxSize is the max total bytes of the cached files, maxFileSize is the max size of a cached file.
+func NewCacheFS(hfs http.FileSystem, maxSize, maxFileSize int64) *CacheFS {
+	return &CacheFS{
+		hfs:     hfs,
+		maxSize: maxSize,
+		maxFile: maxFileSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// SetTTL set the time to live of the cached files, the expired files are reloaded.
+// Default: 0 (never expire)
+func (cfs *CacheFS) SetTTL(ttl time.Duration) {
+	cfs.ttl = ttl
+}
+
+// SetRevalidate set the stat polling interval of the cached files.
+// The cached file is reloaded if the modification time or the size of the underlying file is changed.
+// Default: 0 (never revalidate)
+func (cfs *CacheFS) SetRevalidate(interval time.Duration) {
+	cfs.revalidate = interval
+}
+
+// SetCompress enable to cache the gzip compressed content of the compressible files
+// (text, javascript, json, xml, svg) which size is not less than minSize.
+// The compressed content is served if the request "Accept-Encoding" header accepts gzip.
+// Default: 0 (disabled)
+func (cfs *CacheFS) SetCompress(minSize int) {
+	cfs.compress = minSize
+}
+
+// Len returns the count of the cached files
+func (cfs *CacheFS) Len() int {
+	cfs.mutex.Lock()
+	defer cfs.mutex.Unlock()
+
+	return cfs.lru.Len()
+}
+
+// Size returns the total bytes of the cached files
+func (cfs *CacheFS) Size() int64 {
+	cfs.mutex.Lock()
+	defer cfs.mutex.Unlock()
+
+	return cfs.size
+}
+
+// Clear remove all the cached files
+func (cfs *CacheFS) Clear() {
+	cfs.mutex.Lock()
+	cfs.entries = make(map[string]*list.Element)
+	cfs.lru.Init()
+	cfs.size = 0
+	cfs.mutex.Unlock()
+}
+
+// Open implements http.FileSystem
+func (cfs *CacheFS) Open(name string) (http.File, error) {
+	name = path.Clean("/" + name)
+
+	if ce := cfs.get(name); ce != nil {
+		return newMemFile(ce), nil
+	}
+
+	f, err := cfs.hfs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ce, err := cfs.load(name, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if ce == nil {
+		return f, nil
+	}
+
+	f.Close()
+	if int64(len(ce.data)) <= cfs.maxFile {
+		cfs.put(ce)
+	}
+	return newMemFile(ce), nil
+}
+
+// get returns the valid cached entry of the name, returns nil if not found or invalid
+func (cfs *CacheFS) get(name string) *cacheEntry {
+	now := time.Now()
+
+	cfs.mutex.Lock()
+	le, ok := cfs.entries[name]
+	if !ok {
+		cfs.mutex.Unlock()
+		return nil
+	}
+
+	ce := le.Value.(*cacheEntry)
+	if cfs.ttl > 0 && now.Sub(ce.loaded) > cfs.ttl {
+		cfs.remove(le)
+		cfs.mutex.Unlock()
+		return nil
+	}
+	cfs.lru.MoveToFront(le)
+	check := cfs.revalidate > 0 && now.Sub(ce.checked) > cfs.revalidate
+	cfs.mutex.Unlock()
+
+	if check {
+		if !cfs.validate(ce) {
+			cfs.mutex.Lock()
+			if le, ok := cfs.entries[name]; ok && le.Value == ce {
+				cfs.remove(le)
+			}
+			cfs.mutex.Unlock()
+			return nil
+		}
+
+		cfs.mutex.Lock()
+		ce.checked = now
+		cfs.mutex.Unlock()
+	}
+	return ce
+}
+
+// validate returns true if the underlying file is not modified
+func (cfs *CacheFS) validate(ce *cacheEntry) bool {
+	f, err := cfs.hfs.Open(ce.name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	return err == nil && fi.Size() == ce.info.Size() && fi.ModTime().Equal(ce.info.ModTime())
+}
+
+// load read the file f to the cache entry.
+// Returns nil if the file is a directory or is too large to be cached (the file f is not read).
+func (cfs *CacheFS) load(name string, f http.File) (*cacheEntry, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() || fi.Size() > cfs.maxFile || fi.Size() > cfs.maxSize {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ce := &cacheEntry{name: name, info: fi, data: data, loaded: now, checked: now}
+	ce.etag, _ = hashETag(bytes.NewReader(data))
+
+	if cfs.compress > 0 && len(data) >= cfs.compress && compressible(name) {
+		bb := &bytes.Buffer{}
+		gw := gzip.NewWriter(bb)
+		gw.Write(data) //nolint: errcheck
+		gw.Close()
+		if bb.Len() < len(data) {
+			ce.gzdata = bb.Bytes()
+			ce.gzetag, _ = hashETag(bytes.NewReader(ce.gzdata))
+		}
+	}
+	return ce, nil
+}
+
+// put add the cache entry and evict the least recently used entries
+func (cfs *CacheFS) put(ce *cacheEntry) {
+	size := ce.size()
+
+	cfs.mutex.Lock()
+	defer cfs.mutex.Unlock()
+
+	if le, ok := cfs.entries[ce.name]; ok {
+		cfs.remove(le)
+	}
+
+	for cfs.size+size > cfs.maxSize && cfs.lru.Len() > 0 {
+		cfs.remove(cfs.lru.Back())
+	}
+	if cfs.size+size > cfs.maxSize {
+		return
+	}
+
+	cfs.entries[ce.name] = cfs.lru.PushFront(ce)
+	cfs.size += size
+}
+
+// remove remove the list element from the cache, the mutex should be locked
+func (cfs *CacheFS) remove(le *list.Element) {
+	ce := le.Value.(*cacheEntry)
+	cfs.lru.Remove(le)
+	delete(cfs.entries, ce.name)
+	cfs.size -= ce.size()
+}
+
+func (ce *cacheEntry) size() int64 {
+	return int64(len(ce.data) + len(ce.gzdata))
+}
+
+// compressible returns true if the file is a text file by the file extension
+func compressible(name string) bool {
+	ct := mime.TypeByExtension(path.Ext(name))
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	if strings.HasPrefix(ct, "text/") {
+		return true
+	}
+	switch ct {
+	case "application/javascript", "application/json", "application/xml", "image/svg+xml", "application/wasm":
+		return true
+	}
+	return false
+}
+
+// cachedEntry returns the cache entry of the CacheFS file f, returns nil if f is not a cached file
+func cachedEntry(f http.File) *cacheEntry {
+	if pf, ok := f.(*policyFile); ok {
+		f = pf.File
+	}
+	if mf, ok := f.(*memFile); ok {
+		return mf.ce
+	}
+	return nil
+}
+
+// serveCompressed serve the cached gzip compressed content of the CacheFS file f,
+// or the gzip stream of the deflated ZipFS file f.
+// Returns false if the compressed content is not served.
+func (fh *fileHandler) serveCompressed(w http.ResponseWriter, r *http.Request, name string, f http.File, d fs.FileInfo) bool {
+	var gz io.ReadSeeker
+	var gzetag string
+
+	if ce := cachedEntry(f); ce != nil && ce.gzdata != nil {
+		gz, gzetag = bytes.NewReader(ce.gzdata), ce.gzetag
+	} else if zfl := zippedFile(f); zfl != nil {
+		gz = zfl.gzipReader()
+	}
+	if gz == nil {
+		return false
+	}
+
+	h := w.Header()
+	addVary(h, "Accept-Encoding")
+	if !acceptEncoding(r, "gzip") {
+		return false
+	}
+
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", contentType(name, f))
+	}
+	h.Set("Content-Encoding", "gzip")
+	if fh.etags != nil && h.Get("Etag") == "" {
+		if gzetag == "" {
+			if etag, err := fh.etags.get(name, f, d); err == nil {
+				gzetag = strings.TrimSuffix(etag, `"`) + `-gzip"`
+			}
+		}
+		if gzetag != "" {
+			h.Set("Etag", gzetag)
+		}
+	}
+	http.ServeContent(w, r, d.Name(), d.ModTime(), gz)
+	return true
+}
+
+// memFile a http.File of the cached file
+type memFile struct {
+	*bytes.Reader
+	ce *cacheEntry
+}
+
+func newMemFile(ce *cacheEntry) *memFile {
+	return &memFile{bytes.NewReader(ce.data), ce}
+}
+
+// Close implements http.File
+func (mf *memFile) Close() error {
+	return nil
+}
+
+// Readdir implements http.File
+func (mf *memFile) Readdir(count int) ([]fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "readdir", Path: mf.ce.name, Err: errors.New("not a directory")}
+}
+
+// Stat implements http.File
+func (mf *memFile) Stat() (fs.FileInfo, error) {
+	return mf.ce.info, nil
+}
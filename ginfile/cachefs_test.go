@@ -0,0 +1,177 @@
+package ginfile
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func readCacheFile(t *testing.T, cfs *CacheFS, name string) string {
+	f, err := cfs.Open(name)
+	if err != nil {
+		t.Fatalf("Open(%q): %v", name, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("Read(%q): %v", name, err)
+	}
+	return string(data)
+}
+
+func TestCacheFS(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"a.txt":   []byte("aaaa"),
+		"b.txt":   []byte("bbbb"),
+		"c.txt":   []byte("cccc"),
+		"big.txt": []byte(strings.Repeat("x", 100)),
+	})
+
+	cfs := NewCacheFS(http.Dir(dir), 10, 8)
+
+	if a := readCacheFile(t, cfs, "/a.txt"); a != "aaaa" {
+		t.Errorf("a.txt = %q", a)
+	}
+	readCacheFile(t, cfs, "/b.txt")
+	if cfs.Len() != 2 || cfs.Size() != 8 {
+		t.Errorf("Len() = %d, Size() = %d, want 2, 8", cfs.Len(), cfs.Size())
+	}
+
+	// cached
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("AAAAA"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if a := readCacheFile(t, cfs, "/a.txt"); a != "aaaa" {
+		t.Errorf("cached a.txt = %q", a)
+	}
+
+	// lru: b.txt is evicted
+	readCacheFile(t, cfs, "/c.txt")
+	if cfs.Len() != 2 || cfs.Size() != 8 {
+		t.Errorf("Len() = %d, Size() = %d, want 2, 8", cfs.Len(), cfs.Size())
+	}
+	if _, ok := cfs.entries["/b.txt"]; ok {
+		t.Error("b.txt is not evicted")
+	}
+
+	// too large
+	if a := readCacheFile(t, cfs, "/big.txt"); len(a) != 100 {
+		t.Errorf("big.txt = %q", a)
+	}
+	if _, ok := cfs.entries["/big.txt"]; ok {
+		t.Error("big.txt is cached")
+	}
+
+	// not exist
+	if _, err := cfs.Open("/missing.txt"); !os.IsNotExist(err) {
+		t.Errorf("Open(missing.txt) = %v", err)
+	}
+
+	// directory
+	f, err := cfs.Open("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fis, err := f.Readdir(-1)
+	f.Close()
+	if err != nil || len(fis) != 4 {
+		t.Errorf("Readdir() = %d, %v", len(fis), err)
+	}
+
+	// revalidate
+	cfs.SetRevalidate(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if a := readCacheFile(t, cfs, "/a.txt"); a != "AAAAA" {
+		t.Errorf("revalidated a.txt = %q", a)
+	}
+
+	cfs.Clear()
+	if cfs.Len() != 0 || cfs.Size() != 0 {
+		t.Errorf("Len() = %d, Size() = %d after Clear()", cfs.Len(), cfs.Size())
+	}
+}
+
+func TestCacheFSTTL(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{"a.txt": []byte("a1")})
+
+	cfs := NewCacheFS(http.Dir(dir), 100, 100)
+	cfs.SetTTL(time.Millisecond * 10)
+
+	readCacheFile(t, cfs, "/a.txt")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if a := readCacheFile(t, cfs, "/a.txt"); a != "a1" {
+		t.Errorf("cached a.txt = %q", a)
+	}
+
+	time.Sleep(time.Millisecond * 20)
+	if a := readCacheFile(t, cfs, "/a.txt"); a != "a2" {
+		t.Errorf("expired a.txt = %q", a)
+	}
+}
+
+func TestCacheFSStatic(t *testing.T) {
+	js := strings.Repeat("console.log('app');\n", 100)
+	dir := writeTestFiles(t, map[string][]byte{
+		"app.js":  []byte(js),
+		"img.png": []byte(strings.Repeat("\x89PNG", 100)),
+	})
+
+	cfs := NewCacheFS(http.Dir(dir), 1<<20, 1<<20)
+	cfs.SetCompress(100)
+
+	r := gin.New()
+	StaticFS(r.Group("/c"), "/", "", cfs, "public", HashETag())
+
+	// identity
+	w := testGetCode(t, r, "/c/app.js", 200)
+	if w.Body.String() != js || w.Header().Get("Etag") == "" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("identity = %q, %q, %q", w.Header().Get("Etag"), w.Header().Get("Vary"), w.Body.String())
+	}
+	etag := w.Header().Get("Etag")
+
+	// gzip
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/c/app.js", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	r.ServeHTTP(w, req)
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Etag") == etag {
+		t.Fatalf("gzip Content-Encoding = %q, Etag = %q", w.Header().Get("Content-Encoding"), w.Header().Get("Etag"))
+	}
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(gr)
+	if string(data) != js {
+		t.Errorf("gzip body = %q", data)
+	}
+
+	// not compressible
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/c/img.png", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("img.png = %d, Content-Encoding = %q", w.Code, w.Header().Get("Content-Encoding"))
+	}
+
+	// 304
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/c/app.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match code = %d", w.Code)
+	}
+}
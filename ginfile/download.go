@@ -0,0 +1,170 @@
+package ginfile
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Download serve the files as the attachment with the "Content-Disposition: attachment" header.
+// The filename is the download file name, the base name of the served file is used if filename is empty.
+//
+//	ginfile.StaticFile(g, "/report", "./data/r.pdf", "no-cache", ginfile.Download("レポート.pdf"))
+func Download(filename string) Option {
+	return func(fh *fileHandler) {
+		fh.download = true
+		fh.downloadName = filename
+	}
+}
+
+// ContentDisposition returns the RFC 6266 Content-Disposition header value of the disposition type
+// ("attachment" or "inline") and the filename.
+// The non-ASCII filename is encoded as the RFC 5987 "filename*" parameter,
+// with the ASCII "filename" parameter as the fallback for the old clients.
+//
+//	ContentDisposition("attachment", "a.txt") // attachment; filename="a.txt"
+//	ContentDisposition("attachment", "€ rates.txt") // attachment; filename="_ rates.txt"; filename*=UTF-8''%E2%82%AC%20rates.txt
+func ContentDisposition(dispositionType, filename string) string {
+	if filename == "" {
+		return dispositionType
+	}
+
+	ascii := true
+	fb := make([]byte, 0, len(filename))
+	for _, r := range filename {
+		switch {
+		case r >= 0x80:
+			ascii = false
+			fb = append(fb, '_')
+		case r < 0x20 || r == 0x7f || r == '"' || r == '\\':
+			fb = append(fb, '_')
+		default:
+			fb = append(fb, byte(r))
+		}
+	}
+
+	s := dispositionType + `; filename="` + string(fb) + `"`
+	if !ascii {
+		s += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return s
+}
+
+// encodeRFC5987 percent encode the value except the RFC 5987 attr-char
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	sb := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteByte('%')
+			sb.WriteByte(hex[c>>4])
+			sb.WriteByte(hex[c&15])
+		}
+	}
+	return sb.String()
+}
+
+// setDownload set the Content-Disposition header if the Download option is enabled
+func (fh *fileHandler) setDownload(w http.ResponseWriter, name string) {
+	if !fh.download || w.Header().Get("Content-Disposition") != "" {
+		return
+	}
+
+	filename := fh.downloadName
+	if filename == "" {
+		filename = path.Base(name)
+	}
+	w.Header().Set("Content-Disposition", ContentDisposition("attachment", filename))
+}
+
+// ServeAttachment serve the local file as the attachment with the download filename.
+// The base name of the local file is used if filename is empty.
+// The Range and If-Range requests are supported.
+func ServeAttachment(c *gin.Context, localPath, filename string) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		http.Error(c.Writer, statusText(toHTTPError(err)), toHTTPError(err))
+		return
+	}
+	defer f.Close()
+
+	d, err := f.Stat()
+	if err != nil || d.IsDir() {
+		http.Error(c.Writer, statusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if filename == "" {
+		filename = filepath.Base(localPath)
+	}
+	ServeContentAttachment(c, filename, d.ModTime(), f)
+}
+
+// ServeContentAttachment serve the content as the attachment with the download filename.
+// The Content-Type is detected by the extension of the filename or the content.
+// The Range, If-Range and the conditional requests are supported (see http.ServeContent).
+// Use ServeReaderAttachment for the content which is not seekable.
+func ServeContentAttachment(c *gin.Context, filename string, modtime time.Time, content io.ReadSeeker) {
+	c.Header("Content-Disposition", ContentDisposition("attachment", filename))
+	http.ServeContent(c.Writer, c.Request, filename, modtime, content)
+}
+
+// ServeReaderAttachment serve the content of the reader as the attachment with the download filename.
+// The Content-Type is detected by the extension of the filename, "application/octet-stream" is used if unknown.
+// The size is the Content-Length of the content, -1 if unknown.
+// The Range and the conditional requests are not supported, the whole content is always served with 200 OK.
+func ServeReaderAttachment(c *gin.Context, filename string, size int64, content io.Reader) {
+	h := c.Writer.Header()
+	h.Set("Content-Disposition", ContentDisposition("attachment", filename))
+	if h.Get("Content-Type") == "" {
+		ct := mime.TypeByExtension(path.Ext(filename))
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		h.Set("Content-Type", ct)
+	}
+	if size >= 0 {
+		h.Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+
+	c.Status(http.StatusOK)
+	if c.Request.Method != http.MethodHead {
+		io.Copy(c.Writer, content) //nolint: errcheck
+	}
+}
+
+// StaticContentDownload registers a single route in order to serve the data as the attachment with the download filename.
+// The "ETag" header is set by the content hash of the data.
+// ginfile.StaticContentDownload(gin, "/export.csv", data, time.Now(), "データ.csv", "no-cache")
+func StaticContentDownload(g *gin.RouterGroup, relativePath string, data []byte, modtime time.Time, filename, cacheControl string) {
+	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
+		panic("URL parameters can not be used when serving a static file")
+	}
+
+	if modtime.IsZero() {
+		modtime = time.Now()
+	}
+	etag, _ := hashETag(bytes.NewReader(data))
+	handler := func(c *gin.Context) {
+		if cacheControl != "" {
+			c.Header("Cache-Control", cacheControl)
+		}
+		c.Header("ETag", etag)
+		ServeContentAttachment(c, filename, modtime, bytes.NewReader(data))
+	}
+	g.GET(relativePath, handler)
+	g.HEAD(relativePath, handler)
+}
@@ -0,0 +1,157 @@
+package ginfile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestContentDisposition(t *testing.T) {
+	cs := []struct {
+		typ  string
+		name string
+		want string
+	}{
+		{"attachment", "", "attachment"},
+		{"attachment", "a.txt", `attachment; filename="a.txt"`},
+		{"inline", `a "b".txt`, `inline; filename="a _b_.txt"`},
+		{"attachment", "€ rates.txt", `attachment; filename="_ rates.txt"; filename*=UTF-8''%E2%82%AC%20rates.txt`},
+		{"attachment", "日本.csv", `attachment; filename="__.csv"; filename*=UTF-8''%E6%97%A5%E6%9C%AC.csv`},
+	}
+
+	for i, c := range cs {
+		a := ContentDisposition(c.typ, c.name)
+		if a != c.want {
+			t.Errorf("[%d] ContentDisposition(%q, %q) = %q, want %q", i, c.typ, c.name, a, c.want)
+		}
+	}
+}
+
+func TestDownload(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"a.txt": []byte("0123456789"),
+		"b.csv": []byte("a,b,c"),
+	})
+
+	r := gin.New()
+	StaticFile(r.Group("/"), "/report", filepath.Join(dir, "a.txt"), "", Download("レポート.txt"))
+	Static(r.Group("/files"), "/", dir, "", Download(""))
+
+	w := testGetCode(t, r, "/report", http.StatusOK)
+	if a, want := w.Header().Get("Content-Disposition"), ContentDisposition("attachment", "レポート.txt"); a != want {
+		t.Errorf("Content-Disposition = %q, want %q", a, want)
+	}
+	if a := w.Header().Get("Content-Type"); !strings.HasPrefix(a, "text/plain") {
+		t.Errorf("Content-Type = %q", a)
+	}
+
+	w = testGetCode(t, r, "/files/b.csv", http.StatusOK)
+	if a, want := w.Header().Get("Content-Disposition"), `attachment; filename="b.csv"`; a != want {
+		t.Errorf("Content-Disposition = %q, want %q", a, want)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/report", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "234" {
+		t.Errorf("Range = %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestServeAttachment(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"a.txt": []byte("0123456789"),
+	})
+
+	modtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	r := gin.New()
+	r.GET("/file", func(c *gin.Context) {
+		ServeAttachment(c, filepath.Join(dir, "a.txt"), "")
+	})
+	r.GET("/missing", func(c *gin.Context) {
+		ServeAttachment(c, filepath.Join(dir, "missing.txt"), "m.txt")
+	})
+	r.GET("/content", func(c *gin.Context) {
+		ServeContentAttachment(c, "データ.csv", modtime, strings.NewReader("a,b,c"))
+	})
+	StaticContentDownload(r.Group("/"), "/export", []byte("0123456789"), modtime, "export.txt", "no-cache")
+
+	w := testGetCode(t, r, "/file", http.StatusOK)
+	if a, want := w.Header().Get("Content-Disposition"), `attachment; filename="a.txt"`; a != want {
+		t.Errorf("Content-Disposition = %q, want %q", a, want)
+	}
+
+	testGetCode(t, r, "/missing", http.StatusNotFound)
+
+	w = testGetCode(t, r, "/content", http.StatusOK)
+	if a, want := w.Header().Get("Content-Disposition"), ContentDisposition("attachment", "データ.csv"); a != want {
+		t.Errorf("Content-Disposition = %q, want %q", a, want)
+	}
+	if a := w.Header().Get("Content-Type"); !strings.HasPrefix(a, "text/csv") {
+		t.Errorf("Content-Type = %q", a)
+	}
+
+	w = testGetCode(t, r, "/export", http.StatusOK)
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("header = %v", w.Header())
+	}
+
+	// If-Range
+	for _, ir := range []string{etag, modtime.Format(http.TimeFormat)} {
+		w = httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/export", nil)
+		req.Header.Set("Range", "bytes=0-1")
+		req.Header.Set("If-Range", ir)
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusPartialContent || w.Body.String() != "01" {
+			t.Errorf("If-Range %q = %d %q", ir, w.Code, w.Body.String())
+		}
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/export", nil)
+	req.Header.Set("Range", "bytes=0-1")
+	req.Header.Set("If-Range", `"other"`)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" {
+		t.Errorf("If-Range mismatch = %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestServeReaderAttachment(t *testing.T) {
+	r := gin.New()
+	r.GET("/reader", func(c *gin.Context) {
+		ServeReaderAttachment(c, "データ.csv", 5, strings.NewReader("a,b,c"))
+	})
+	r.GET("/unknown", func(c *gin.Context) {
+		ServeReaderAttachment(c, "data", -1, strings.NewReader("0123456789"))
+	})
+
+	// the Range request is ignored
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/reader", nil)
+	req.Header.Set("Range", "bytes=0-1")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "a,b,c" {
+		t.Errorf("/reader = %d %q", w.Code, w.Body.String())
+	}
+	if a, want := w.Header().Get("Content-Disposition"), ContentDisposition("attachment", "データ.csv"); a != want {
+		t.Errorf("Content-Disposition = %q, want %q", a, want)
+	}
+	if a := w.Header().Get("Content-Type"); !strings.HasPrefix(a, "text/csv") || w.Header().Get("Content-Length") != "5" {
+		t.Errorf("header = %v", w.Header())
+	}
+
+	w = testGetCode(t, r, "/unknown", http.StatusOK)
+	if w.Body.String() != "0123456789" || w.Header().Get("Content-Type") != "application/octet-stream" || w.Header().Get("Content-Length") != "" {
+		t.Errorf("/unknown = %v %q", w.Header(), w.Body.String())
+	}
+}
@@ -0,0 +1,149 @@
+package ginfile
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// encoding a precompressed content encoding
+type encoding struct {
+	name string // the Content-Encoding name
+	ext  string // the sidecar file extension
+}
+
+var knownEncodings = map[string]encoding{
+	"br":   {"br", ".br"},
+	"gzip": {"gzip", ".gz"},
+}
+
+// Precompressed serve the precompressed sidecar file (e.g. "app.js.br", "app.js.gz")
+// instead of the original file "app.js" if the sidecar file exists and
+// the request "Accept-Encoding" header accepts the encoding.
+// The sidecar file is served with the "Content-Encoding" header, the "Content-Type" of the
+// original file and the "Vary: Accept-Encoding" header.
+// The encodings are searched by the order, the default encodings are "br", "gzip".
+// Panic if the encoding is not "br" or "gzip".
+func Precompressed(encodings ...string) Option {
+	if len(encodings) == 0 {
+		encodings = []string{"br", "gzip"}
+	}
+
+	ens := make([]encoding, len(encodings))
+	for i, e := range encodings {
+		en, ok := knownEncodings[e]
+		if !ok {
+			panic("ginfile: unsupported precompressed encoding " + strconv.Quote(e))
+		}
+		ens[i] = en
+	}
+
+	return func(fh *fileHandler) {
+		fh.encodings = ens
+	}
+}
+
+// serveEncoded serve the precompressed sidecar file of the file f.
+// Returns false if the sidecar file is not served.
+func (fh *fileHandler) serveEncoded(w http.ResponseWriter, r *http.Request, name string, f http.File, d fs.FileInfo) bool {
+	vary := false
+	for _, en := range fh.encodings {
+		ef, err := fh.hfs.Open(name + en.ext)
+		if err != nil {
+			continue
+		}
+
+		ed, err := ef.Stat()
+		if err != nil || ed.IsDir() {
+			ef.Close()
+			continue
+		}
+
+		vary = true
+		if !acceptEncoding(r, en.name) {
+			ef.Close()
+			continue
+		}
+
+		defer ef.Close()
+
+		h := w.Header()
+		addVary(h, "Accept-Encoding")
+		if h.Get("Content-Type") == "" {
+			h.Set("Content-Type", contentType(name, f))
+		}
+		h.Set("Content-Encoding", en.name)
+
+		modtime := d.ModTime()
+		if ed.ModTime().After(modtime) {
+			modtime = ed.ModTime()
+		}
+		fh.setETag(w, name+en.ext, ef, ed)
+		http.ServeContent(w, r, d.Name(), modtime, ef)
+		return true
+	}
+
+	if vary {
+		addVary(w.Header(), "Accept-Encoding")
+	}
+	return false
+}
+
+// contentType returns the content type of the file by the file extension,
+// or by the content sniffing if the file extension is unknown.
+func contentType(name string, f http.File) string {
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		return ct
+	}
+
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(f, buf)
+	f.Seek(0, io.SeekStart) //nolint: errcheck
+	return http.DetectContentType(buf[:n])
+}
+
+// acceptEncoding returns true if the request "Accept-Encoding" header accepts the encoding
+func acceptEncoding(r *http.Request, encoding string) bool {
+	wildcard := false
+	for _, ae := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, q := parseQuality(ae)
+		switch {
+		case strings.EqualFold(name, encoding):
+			return q > 0
+		case name == "*":
+			wildcard = q > 0
+		}
+	}
+	return wildcard
+}
+
+// parseQuality parse the "name;q=0.5" value, returns the name and the quality value
+func parseQuality(s string) (string, float64) {
+	name, q := strings.TrimSpace(s), 1.0
+	if i := strings.IndexByte(name, ';'); i >= 0 {
+		ps := strings.TrimSpace(name[i+1:])
+		name = strings.TrimSpace(name[:i])
+		if strings.HasPrefix(ps, "q=") {
+			if f, err := strconv.ParseFloat(ps[2:], 64); err == nil {
+				q = f
+			}
+		}
+	}
+	return name, q
+}
+
+// addVary add the value to the "Vary" header if it is not present
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
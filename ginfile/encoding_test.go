@@ -0,0 +1,132 @@
+package ginfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func writeTestFiles(t *testing.T, files map[string][]byte) string {
+	dir := t.TempDir()
+	for name, data := range files {
+		fp := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func gzipBytes(data []byte) []byte {
+	bb := &bytes.Buffer{}
+	gw := gzip.NewWriter(bb)
+	gw.Write(data) //nolint: errcheck
+	gw.Close()
+	return bb.Bytes()
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	cs := []struct {
+		accept   string
+		encoding string
+		want     bool
+	}{
+		{"", "gzip", false},
+		{"gzip", "gzip", true},
+		{"deflate, GZIP", "gzip", true},
+		{"gzip;q=0", "gzip", false},
+		{"br;q=0.5, gzip;q=1.0", "br", true},
+		{"*", "br", true},
+		{"*;q=0", "br", false},
+		{"*, br;q=0", "br", false},
+		{"gzip", "br", false},
+	}
+
+	for i, c := range cs {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", c.accept)
+		if a := acceptEncoding(r, c.encoding); a != c.want {
+			t.Errorf("[%d] acceptEncoding(%q, %q) = %v, want %v", i, c.accept, c.encoding, a, c.want)
+		}
+	}
+}
+
+func TestPrecompressed(t *testing.T) {
+	js := []byte("console.log('app.js');")
+	gz := gzipBytes(js)
+	br := []byte("fake brotli")
+	dir := writeTestFiles(t, map[string][]byte{
+		"app.js":            js,
+		"app.js.gz":         gz,
+		"app.js.br":         br,
+		"plain.txt":         []byte("plain.txt"),
+		"data":              []byte("<html><body>data</body></html>"),
+		"data.gz":           gzipBytes([]byte("<html><body>data</body></html>")),
+		"d1/index.css":      []byte("body{}"),
+		"d1/index.css.gz/x": []byte("not a sidecar"),
+	})
+
+	r := gin.New()
+	g := r.Group("/web")
+	Static(g, "/", dir, "public", Precompressed())
+	StaticFile(&r.RouterGroup, "/file.js", filepath.Join(dir, "app.js"), "", Precompressed("gzip"))
+
+	jsType := mime.TypeByExtension(".js")
+	cs := []struct {
+		path     string
+		accept   string
+		rng      string
+		code     int
+		encoding string
+		ctype    string
+		vary     bool
+		body     []byte
+	}{
+		{"/web/app.js", "gzip, deflate, br", "", 200, "br", jsType, true, br},
+		{"/web/app.js", "gzip", "", 200, "gzip", jsType, true, gz},
+		{"/web/app.js", "gzip, br;q=0", "", 200, "gzip", jsType, true, gz},
+		{"/web/app.js", "", "", 200, "", jsType, true, js},
+		{"/web/app.js", "br", "bytes=0-3", 206, "br", jsType, true, br[:4]},
+		{"/web/plain.txt", "gzip, br", "", 200, "", "text/plain; charset=utf-8", false, []byte("plain.txt")},
+		{"/web/data", "gzip", "", 200, "gzip", "text/html; charset=utf-8", true, gzipBytes([]byte("<html><body>data</body></html>"))},
+		{"/web/d1/index.css", "gzip", "", 200, "", mime.TypeByExtension(".css"), false, []byte("body{}")},
+		{"/file.js", "gzip, br", "", 200, "gzip", jsType, true, gz},
+	}
+
+	for i, c := range cs {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.accept != "" {
+			req.Header.Set("Accept-Encoding", c.accept)
+		}
+		if c.rng != "" {
+			req.Header.Set("Range", c.rng)
+		}
+		r.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("[%d] %s code = %d, want %d", i, c.path, w.Code, c.code)
+		}
+		if a := w.Header().Get("Content-Encoding"); a != c.encoding {
+			t.Errorf("[%d] %s Content-Encoding = %q, want %q", i, c.path, a, c.encoding)
+		}
+		if a := w.Header().Get("Content-Type"); a != c.ctype {
+			t.Errorf("[%d] %s Content-Type = %q, want %q", i, c.path, a, c.ctype)
+		}
+		if a := w.Header().Get("Vary") == "Accept-Encoding"; a != c.vary {
+			t.Errorf("[%d] %s Vary = %q, want %v", i, c.path, w.Header().Get("Vary"), c.vary)
+		}
+		if !bytes.Equal(w.Body.Bytes(), c.body) {
+			t.Errorf("[%d] %s body = %q, want %q", i, c.path, w.Body.Bytes(), c.body)
+		}
+	}
+}
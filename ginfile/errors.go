@@ -0,0 +1,168 @@
+package ginfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler the static file error handler, status is the http status code of the error
+// (e.g. http.StatusNotFound, http.StatusForbidden, http.StatusInternalServerError).
+type ErrorHandler func(c *gin.Context, status int)
+
+// ErrorPage serve the file of the static mount file system as the error page of the status code.
+// The error page is served with the status code.
+//
+//	ginfile.Static(g, "/", "./public", "", ginfile.ErrorPage(http.StatusNotFound, "/404.html"))
+func ErrorPage(status int, file string) Option {
+	file = path.Clean("/" + file)
+	return func(fh *fileHandler) {
+		if fh.errorPages == nil {
+			fh.errorPages = make(map[int]string)
+		}
+		fh.errorPages[status] = file
+	}
+}
+
+// HandleError set the error handler of the static mount.
+// The error handler is called if no error page of the status code is configured.
+func HandleError(eh ErrorHandler) Option {
+	return func(fh *fileHandler) {
+		fh.errorHandler = eh
+	}
+}
+
+// NoRoute delegate the "404 Not Found" error to the handlers
+// (e.g. the same handlers of the gin.Engine.NoRoute()).
+// The response status is set to 404 before the handlers are called.
+// The handlers are called if no error page of 404 and no error handler are configured.
+// The handlers are called as a gin handler chain (c.Next() calls the next no route handler)
+// with the keys of the gin.Context, the errors and the abort state are reported back to the gin.Context.
+func NoRoute(handlers ...gin.HandlerFunc) Option {
+	return func(fh *fileHandler) {
+		if len(handlers) == 0 {
+			fh.noRoute = nil
+			return
+		}
+
+		engine := gin.New()
+		engine.RedirectTrailingSlash = false
+		engine.RedirectFixedPath = false
+		engine.Use(bridgeContext)
+		engine.NoRoute(handlers...)
+		fh.noRoute = engine
+	}
+}
+
+// bridgeContext a middleware of the no route engine which bridges the gin.Context nc
+// of the no route engine and the gin.Context of the static mount.
+func bridgeContext(nc *gin.Context) {
+	c, ok := nc.Request.Context().Value(ginContextKey{}).(*gin.Context)
+	if !ok {
+		nc.Next()
+		return
+	}
+
+	nc.Keys = c.Keys
+	nc.Next()
+	c.Keys = nc.Keys
+	c.Errors = append(c.Errors, nc.Errors...)
+	if nc.IsAborted() {
+		c.Abort()
+	}
+}
+
+// ginContextKey the request context key of the *gin.Context
+type ginContextKey struct{}
+
+// request returns the request to be served, the *gin.Context is stored to
+// the request context if the error handler, the no route handlers or the rich listing are configured.
+func (fh *fileHandler) request(c *gin.Context) *http.Request {
+	if fh.errorHandler == nil && fh.noRoute == nil && fh.listing == nil {
+		return c.Request
+	}
+	return c.Request.WithContext(context.WithValue(c.Request.Context(), ginContextKey{}, c))
+}
+
+// serveError write the http error of the file open/stat error
+func (fh *fileHandler) serveError(w http.ResponseWriter, r *http.Request, err error) {
+	fh.serveStatus(w, r, toHTTPError(err))
+}
+
+// serveStatus write the http error of the status code by the error page, the error handler,
+// the no route handlers, or the plain text message.
+func (fh *fileHandler) serveStatus(w http.ResponseWriter, r *http.Request, status int) {
+	if ep, ok := fh.errorPages[status]; ok && fh.serveErrorPage(w, r, status, ep) {
+		return
+	}
+
+	if c, ok := r.Context().Value(ginContextKey{}).(*gin.Context); ok {
+		if fh.errorHandler != nil {
+			fh.errorHandler(c, status)
+			return
+		}
+		if status == http.StatusNotFound && fh.noRoute != nil {
+			// serve the original request of the gin.Context with the context which holds the gin.Context
+			fh.noRoute.ServeHTTP(w, c.Request.WithContext(r.Context()))
+			return
+		}
+	}
+
+	http.Error(w, statusText(status), status)
+}
+
+// serveErrorPage serve the error page file with the status code.
+// Returns false if the error page file can not be opened.
+func (fh *fileHandler) serveErrorPage(w http.ResponseWriter, r *http.Request, status int, name string) bool {
+	f, err := fh.hfs.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	d, err := f.Stat()
+	if err != nil || d.IsDir() {
+		return false
+	}
+
+	h := w.Header()
+	for _, k := range []string{"Cache-Control", "Content-Encoding", "Etag", "Expires", "Last-Modified", "Vary"} {
+		h.Del(k)
+	}
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Content-Type", contentType(name, f))
+	h.Set("Content-Length", fmt.Sprint(d.Size()))
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+
+	if r.Method != http.MethodHead {
+		io.Copy(w, f) //nolint: errcheck
+	}
+	return true
+}
+
+// statusText returns the error message of the status code like http.FileServer
+func statusText(status int) string {
+	if status == http.StatusNotFound {
+		return "404 page not found"
+	}
+	return fmt.Sprintf("%d %s", status, http.StatusText(status))
+}
+
+// toHTTPError returns the http status code of the file open/stat error
+func toHTTPError(err error) int {
+	if errors.Is(err, fs.ErrNotExist) || os.IsNotExist(err) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, fs.ErrPermission) || os.IsPermission(err) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,141 @@
+package ginfile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorPage(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"index.html":   []byte("index.html"),
+		"404.html":     []byte("<h1>not found</h1>"),
+		"d1/a.txt":     []byte("a.txt"),
+		"errors/.keep": []byte(""),
+	})
+
+	r := gin.New()
+	Static(r.Group("/p"), "/", dir, "public", ErrorPage(http.StatusNotFound, "404.html"), ErrorPage(http.StatusForbidden, "/missing.html"), NoListing(http.StatusForbidden))
+	StaticSPA(r.Group("/spa"), "/", http.Dir(dir), "index.html", "", SPAExclude("/spa/api/"), ErrorPage(http.StatusNotFound, "/404.html"))
+
+	cs := []struct {
+		method string
+		path   string
+		code   int
+		ctype  string
+		body   string
+	}{
+		{"GET", "/p/missing.txt", 404, "text/html; charset=utf-8", "<h1>not found</h1>"},
+		{"HEAD", "/p/missing.txt", 404, "text/html; charset=utf-8", ""},
+		{"GET", "/p/d1/", 403, "text/plain; charset=utf-8", "403 Forbidden\n"},
+		{"GET", "/p/d1/a.txt", 200, "text/plain; charset=utf-8", "a.txt"},
+		{"GET", "/spa/api/users", 404, "text/html; charset=utf-8", "<h1>not found</h1>"},
+	}
+
+	for i, c := range cs {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+
+		if w.Code != c.code {
+			t.Errorf("[%d] %s code = %d, want %d", i, c.path, w.Code, c.code)
+		}
+		if a := w.Header().Get("Content-Type"); a != c.ctype {
+			t.Errorf("[%d] %s Content-Type = %q, want %q", i, c.path, a, c.ctype)
+		}
+		if a := w.Body.String(); a != c.body {
+			t.Errorf("[%d] %s body = %q, want %q", i, c.path, a, c.body)
+		}
+		if c.code != 200 && w.Header().Get("Cache-Control") == "public" {
+			t.Errorf("[%d] %s error page Cache-Control = %q", i, c.path, w.Header().Get("Cache-Control"))
+		}
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	r := gin.New()
+	Static(r.Group("/eh"), "/", "testdata", "", NoListing(http.StatusForbidden), HandleError(func(c *gin.Context, status int) {
+		c.JSON(status, gin.H{"status": status, "path": c.Request.URL.Path})
+	}))
+
+	notFound := func(c *gin.Context) {
+		c.String(c.Writer.Status(), "no route: %s", c.Request.URL.Path)
+	}
+	r.NoRoute(notFound)
+	StaticFS(r.Group("/nr"), "/", "/testdata", http.FS(testdata), "", NoRoute(notFound))
+
+	cs := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/eh/missing", 404, `{"path":"/eh/missing","status":404}`},
+		{"/eh/d1/", 403, `{"path":"/eh/d1/","status":403}`},
+		{"/nr/missing", 404, `no route: /nr/missing`},
+		{"/nr/d1/", 200, ``},
+		{"/unknown", 404, `no route: /unknown`},
+	}
+
+	for i, c := range cs {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", c.path, nil))
+
+		if w.Code != c.code {
+			t.Errorf("[%d] %s code = %d, want %d", i, c.path, w.Code, c.code)
+		}
+		if c.body != "" && w.Body.String() != c.body {
+			t.Errorf("[%d] %s body = %q, want %q", i, c.path, w.Body.String(), c.body)
+		}
+	}
+}
+
+func TestNoRouteChain(t *testing.T) {
+	var calls []string
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", "u1")
+		c.Next()
+		calls = append(calls, "middleware:"+c.GetString("nr"))
+	})
+	StaticFS(r.Group("/nr"), "/", "/testdata", http.FS(testdata), "", NoRoute(
+		func(c *gin.Context) {
+			calls = append(calls, "nr1-before")
+			c.Next()
+			calls = append(calls, "nr1-after")
+		},
+		func(c *gin.Context) {
+			calls = append(calls, "nr2")
+			c.Set("nr", "done")
+			c.String(c.Writer.Status(), "no route: %s %s", c.GetString("user"), c.Request.URL.Path)
+		},
+	))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/nr/missing", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "no route: u1 /nr/missing" {
+		t.Errorf("code = %d, body = %q", w.Code, w.Body.String())
+	}
+
+	want := []string{"nr1-before", "nr2", "nr1-after", "middleware:done"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", calls, want)
+		}
+	}
+}
+
+func TestURLReplaceNotFound(t *testing.T) {
+	fh := newFileHandler(http.FS(testdata), "", ErrorPage(http.StatusNotFound, "/testdata/d1/d1f1.txt"))
+	hh := fh.urlReplace("/static", "/testdata", fh)
+
+	w := httptest.NewRecorder()
+	hh.ServeHTTP(w, httptest.NewRequest("GET", "/other/a.txt", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("code = %d, header = %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,84 @@
+package ginfile
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HashETag set the strong "ETag" header by the content hash (SHA-256) of the file,
+// the "If-None-Match" request is answered with "304 Not Modified" if the ETag matches.
+// The hash is cached per file and is recalculated when the modification time or the size of the file is changed.
+// The precompressed sidecar file has its own ETag.
+func HashETag() Option {
+	return func(fh *fileHandler) {
+		fh.etags = &etagCache{entries: make(map[string]*etagEntry)}
+	}
+}
+
+// etagCache the content hash ETag cache
+type etagCache struct {
+	mutex   sync.RWMutex
+	entries map[string]*etagEntry
+}
+
+type etagEntry struct {
+	modtime time.Time
+	size    int64
+	etag    string
+}
+
+// get returns the cached ETag of the file, calculate the ETag if the cache is missing or stale
+func (ec *etagCache) get(name string, f http.File, d fs.FileInfo) (string, error) {
+	ec.mutex.RLock()
+	ee, ok := ec.entries[name]
+	ec.mutex.RUnlock()
+
+	if ok && ee.size == d.Size() && ee.modtime.Equal(d.ModTime()) {
+		return ee.etag, nil
+	}
+
+	etag, err := hashETag(f)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	ec.mutex.Lock()
+	ec.entries[name] = &etagEntry{modtime: d.ModTime(), size: d.Size(), etag: etag}
+	ec.mutex.Unlock()
+
+	return etag, nil
+}
+
+// setETag set the "ETag" header of the file if the HashETag option is enabled
+// and the "ETag" header is not set.
+func (fh *fileHandler) setETag(w http.ResponseWriter, name string, f http.File, d fs.FileInfo) {
+	if fh.etags == nil || w.Header().Get("Etag") != "" {
+		return
+	}
+
+	if ce := cachedEntry(f); ce != nil {
+		w.Header().Set("Etag", ce.etag)
+		return
+	}
+
+	if etag, err := fh.etags.get(name, f, d); err == nil {
+		w.Header().Set("Etag", etag)
+	}
+}
+
+// hashETag returns the strong ETag of the content hash of the reader
+func hashETag(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return `"` + base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:18]) + `"`, nil
+}
@@ -0,0 +1,79 @@
+package ginfile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testGetETag(t *testing.T, r *gin.Engine, path string, header map[string]string, code int) string {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	r.ServeHTTP(w, req)
+
+	if w.Code != code {
+		t.Errorf("%s %v code = %d, want %d", path, header, w.Code, code)
+	}
+	return w.Header().Get("ETag")
+}
+
+func TestHashETag(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"a.txt":    []byte("a.txt"),
+		"a.txt.gz": gzipBytes([]byte("a.txt")),
+	})
+
+	r := gin.New()
+	Static(r.Group("/disk"), "/", dir, "", HashETag(), Precompressed())
+	StaticFS(r.Group("/embed"), "/", "/testdata", http.FS(testdata), "", HashETag())
+
+	etag := testGetETag(t, r, "/disk/a.txt", nil, http.StatusOK)
+	if len(etag) < 3 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		t.Fatalf("ETag = %q, want strong etag", etag)
+	}
+
+	testGetETag(t, r, "/disk/a.txt", map[string]string{"If-None-Match": etag}, http.StatusNotModified)
+	testGetETag(t, r, "/disk/a.txt", map[string]string{"If-None-Match": `"other"`}, http.StatusOK)
+	testGetETag(t, r, "/disk/a.txt", map[string]string{"Range": "bytes=0-0", "If-Range": etag}, http.StatusPartialContent)
+	testGetETag(t, r, "/disk/a.txt", map[string]string{"Range": "bytes=0-0", "If-Range": `"other"`}, http.StatusOK)
+
+	getag := testGetETag(t, r, "/disk/a.txt", map[string]string{"Accept-Encoding": "gzip"}, http.StatusOK)
+	if getag == "" || getag == etag {
+		t.Errorf("gzip ETag = %q, identity ETag = %q", getag, etag)
+	}
+
+	// modify file
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a.txt modified"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	etag2 := testGetETag(t, r, "/disk/a.txt", map[string]string{"If-None-Match": etag}, http.StatusOK)
+	if etag2 == "" || etag2 == etag {
+		t.Errorf("modified ETag = %q, old ETag = %q", etag2, etag)
+	}
+
+	// embed.FS (zero modtime)
+	eetag := testGetETag(t, r, "/embed/r1.txt", nil, http.StatusOK)
+	if eetag == "" {
+		t.Fatal("embed ETag is empty")
+	}
+	testGetETag(t, r, "/embed/r1.txt", map[string]string{"If-None-Match": eetag}, http.StatusNotModified)
+}
+
+func TestStaticContentETag(t *testing.T) {
+	r := gin.New()
+	StaticContent(&r.RouterGroup, "/d1/d1f1.txt", d1f1, time.Now(), "no-store")
+
+	etag := testGetETag(t, r, "/d1/d1f1.txt", nil, http.StatusOK)
+	if etag == "" {
+		t.Fatal("ETag is empty")
+	}
+	testGetETag(t, r, "/d1/d1f1.txt", map[string]string{"If-None-Match": etag}, http.StatusNotModified)
+}
@@ -15,113 +15,91 @@ import (
 // Public1Year Cache-Control: public, max-age=31536000
 const Public1Year = "public, max-age=31536000"
 
-func getCacheControlWriter(c *gin.Context, cacheControl string) http.ResponseWriter {
-	if cacheControl == "" {
-		return c.Writer
-	}
-	h := map[string]string{"Cache-Control": cacheControl}
-	return &headerWriter{c.Writer, h}
-}
-
-// headerWriter write header when statusCode == 200 on WriteHeader(statusCode int)
-// a existing header will not be overwriten.
-type headerWriter struct {
-	http.ResponseWriter
-	header map[string]string
-}
-
-// WriteHeader append header when statusCode == 200
-func (hw *headerWriter) WriteHeader(statusCode int) {
-	if statusCode == http.StatusOK {
-		for k, v := range hw.header {
-			if hw.Header().Get(k) == "" {
-				hw.Header().Add(k, v)
-			}
-		}
-	}
-	hw.ResponseWriter.WriteHeader(statusCode)
-}
-
 // AppendPrefix returns a handler that serves HTTP requests by appending the
-// given prefix from the request URL's Path (and RawPath if set) and invoking
-// the handler hh.
+// given prefix to the cleaned request URL's Path and invoking the handler hh.
+// The ".." elements of the request URL's Path can not escape the prefix.
 func appendPrefix(prefix string, hh http.Handler) http.Handler {
 	if prefix == "" {
 		return hh
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		p := prefix + r.URL.Path
-		rp := prefix + r.URL.RawPath
-		r2 := new(http.Request)
-		*r2 = *r
-		r2.URL = new(url.URL)
-		*r2.URL = *r.URL
-		r2.URL.Path = p
-		r2.URL.RawPath = rp
-		hh.ServeHTTP(w, r2)
+		hh.ServeHTTP(w, replacePath(r, prefix+cleanPath(r.URL.Path)))
 	})
 }
 
 // URLReplace returns a handler that serves HTTP requests by replacing the
-// request URL's Path (and RawPath if set) (use strings.Replace(path, src, des) and invoking
-// the handler hh.
-func urlReplace(src, des string, hh http.Handler) http.Handler {
+// prefix src of the cleaned request URL's Path with des and invoking the handler hh.
+// The ".." elements of the request URL's Path can not escape the prefix des.
+// The request is replied with the "404 Not Found" error of the file handler if the path does not start with src.
+func (fh *fileHandler) urlReplace(src, des string, hh http.Handler) http.Handler {
 	if src == "" || src == des {
 		return hh
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		p := strings.Replace(r.URL.Path, src, des, 1)
-		rp := strings.Replace(r.URL.RawPath, src, des, 1)
-		r2 := new(http.Request)
-		*r2 = *r
-		r2.URL = new(url.URL)
-		*r2.URL = *r.URL
-		r2.URL.Path = p
-		r2.URL.RawPath = rp
-		hh.ServeHTTP(w, r2)
+		p := r.URL.Path
+		if !strings.HasPrefix(p, src) {
+			fh.serveStatus(w, r, http.StatusNotFound)
+			return
+		}
+		hh.ServeHTTP(w, replacePath(r, des+cleanPath(p[len(src):])))
 	})
 }
 
+// replacePath returns a shallow copy of the request r with the new URL path p
+func replacePath(r *http.Request, p string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = p
+	r2.URL.RawPath = ""
+	return r2
+}
+
 // Static serves files from the given file system root.
-func Static(g *gin.RouterGroup, relativePath, localPath, cacheControl string) {
-	StaticFS(g, relativePath, "", http.Dir(localPath), cacheControl)
+// The cacheControl is the default Cache-Control header of the served files (see CacheRules).
+func Static(g *gin.RouterGroup, relativePath, localPath, cacheControl string, opts ...Option) {
+	StaticFS(g, relativePath, "", http.Dir(localPath), cacheControl, opts...)
 }
 
 // StaticFile registers a single route in order to serve a single file of the local filesystem.
 // ginfile.StaticFSFile(gin, "favicon.ico", "./resources/favicon.ico", "public")
-func StaticFile(g *gin.RouterGroup, relativePath, localPath, cacheControl string) {
+func StaticFile(g *gin.RouterGroup, relativePath, localPath, cacheControl string, opts ...Option) {
 	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
 		panic("URL parameters can not be used when serving a static file")
 	}
 
+	hfs := http.Dir(filepath.Dir(localPath))
+	fh := newFileHandler(hfs, cacheControl, opts...)
+	fh.hfs = hfs // the caller chosen file is served regardless of the dotfile and deny policy
+	name := "/" + filepath.Base(localPath)
 	handler := func(c *gin.Context) {
-		ccw := getCacheControlWriter(c, cacheControl)
-		http.ServeFile(ccw, c.Request, localPath)
+		fh.serveFile(c.Writer, fh.request(c), name, false)
 	}
 	g.GET(relativePath, handler)
 	g.HEAD(relativePath, handler)
 }
 
 // StaticFS works just like `Static()` but a custom `http.FileSystem` can be used instead.
-func StaticFS(g *gin.RouterGroup, relativePath string, localPath string, hfs http.FileSystem, cacheControl string) {
+func StaticFS(g *gin.RouterGroup, relativePath string, localPath string, hfs http.FileSystem, cacheControl string, opts ...Option) {
 	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
 		panic("URL parameters can not be used when serving a static folder")
 	}
 
 	prefix := path.Join(g.BasePath(), relativePath)
-	fileServer := http.FileServer(hfs)
+	fh := newFileHandler(hfs, cacheControl, opts...)
+	var fileServer http.Handler = fh
 	if prefix == "" || prefix == "/" {
 		fileServer = appendPrefix(localPath, fileServer)
 	} else if localPath == "" || localPath == "." {
 		fileServer = http.StripPrefix(prefix, fileServer)
 	} else {
-		fileServer = urlReplace(prefix, localPath, fileServer)
+		fileServer = fh.urlReplace(prefix, localPath, fileServer)
 	}
 
 	handler := func(c *gin.Context) {
-		ccw := getCacheControlWriter(c, cacheControl)
-		fileServer.ServeHTTP(ccw, c.Request)
+		fileServer.ServeHTTP(c.Writer, fh.request(c))
 	}
 
 	urlPattern := path.Join(relativePath, "/*path")
@@ -133,20 +111,16 @@ func StaticFS(g *gin.RouterGroup, relativePath string, localPath string, hfs htt
 
 // StaticFSFile registers a single route in order to serve a single file of the filesystem.
 // ginfile.StaticFSFile(gin, "favicon.ico", "./resources/favicon.ico", hfs, ginfile.Public1Year)
-func StaticFSFile(g *gin.RouterGroup, relativePath, filePath string, hfs http.FileSystem, cacheControl string) {
+func StaticFSFile(g *gin.RouterGroup, relativePath, filePath string, hfs http.FileSystem, cacheControl string, opts ...Option) {
 	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
 		panic("URL parameters can not be used when serving a static file")
 	}
 
+	fh := newFileHandler(hfs, cacheControl, opts...)
+	fh.hfs = hfs // the caller chosen file is served regardless of the dotfile and deny policy
+	name := path.Clean("/" + filePath)
 	handler := func(c *gin.Context) {
-		defer func(old string) {
-			c.Request.URL.Path = old
-		}(c.Request.URL.Path)
-
-		c.Request.URL.Path = filePath
-		ccw := getCacheControlWriter(c, cacheControl)
-
-		http.FileServer(hfs).ServeHTTP(ccw, c.Request)
+		fh.serveFile(c.Writer, fh.request(c), name, false)
 	}
 
 	g.GET(relativePath, handler)
@@ -154,6 +128,7 @@ func StaticFSFile(g *gin.RouterGroup, relativePath, filePath string, hfs http.Fi
 }
 
 // StaticContent registers a single route in order to serve a single file of the data.
+// The "ETag" header is set by the content hash of the data.
 // //go:embed favicon.ico
 // var favicon []byte
 // ginfile.StaticContent(gin, "favicon.ico", favicon, time.Now(), "public")
@@ -165,10 +140,12 @@ func StaticContent(g *gin.RouterGroup, relativePath string, data []byte, modtime
 	if modtime.IsZero() {
 		modtime = time.Now()
 	}
+	etag, _ := hashETag(bytes.NewReader(data))
 	handler := func(c *gin.Context) {
 		if cacheControl != "" {
 			c.Header("Cache-Control", cacheControl)
 		}
+		c.Header("ETag", etag)
 		name := filepath.Base(c.Request.URL.Path)
 		http.ServeContent(c.Writer, c.Request, name, modtime, bytes.NewReader(data))
 	}
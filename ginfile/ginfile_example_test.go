@@ -23,12 +23,68 @@ func Example() {
 	// static serve file: "/r1.txt" -> "./file.txt" with "public" cache-control
 	StaticFile(&router.RouterGroup, "/r1.txt", "./file.txt", "public")
 
+	// static serve path with the precompressed ".br", ".gz" files and content hash ETag: "/assets" -> "./assets" with 1 year cache-control
+	Static(&router.RouterGroup, "/assets", "./assets", Public1Year, Precompressed(), HashETag())
+
 	// static serve FS path: "/fs" -> "fs:/fsdir" with "public" cache-control
 	StaticFS(&router.RouterGroup, "/fs", "/fsdir", http.FS(fsdata), "public")
 
 	// static serve FS file: "/r2.txt" -> "fs:/fsdir/r2.txt" with "public" cache-control
 	StaticFSFile(&router.RouterGroup, "/r2.txt", "fsdir/r2.txt", http.FS(fsdata), "public")
 
+	// static serve path with Cache-Control rules: "/web" -> "./web"
+	Static(&router.RouterGroup, "/web", "./web", "public, max-age=3600", CacheRules(
+		CacheRegexp(`\.[0-9a-f]{8,}\.(js|css)$`, "public, max-age=31536000, immutable"),
+		CacheGlob("*.html", "no-cache"),
+	), CacheExpires())
+
+	// static serve path with the security policy (the dotfiles are denied by default): "/files" -> "./files"
+	Static(&router.RouterGroup, "/files", "./files", "private", Deny("*.bak", "node_modules"), DenySymlinkEscape(), NoListing(http.StatusNotFound))
+
+	// static serve path with the in-memory cache (64MB total, 1MB per file): "/hot" -> "./hot"
+	cfs := NewCacheFS(http.Dir("./hot"), 64<<20, 1<<20)
+	cfs.SetRevalidate(time.Second * 5)
+	StaticFS(&router.RouterGroup, "/hot", "", cfs, Public1Year)
+
+	// static serve fingerprinted assets: "/static/app.3f9a1c2b.js" -> "fs:/fsdir/app.js" with immutable cache-control
+	// use assets.URL("app.js") or the ginhtml template function {{asset "app.js"}} to get the fingerprinted URL
+	assets, _ := NewAssets(fsdata)
+	assets.Mount(router.Group("/static"), "/")
+
+	// static serve single page application: "/app" -> "./dist" with "index.html" fallback
+	StaticSPA(router.Group("/app"), "/", http.Dir("./dist"), "index.html", Public1Year, SPAExclude("/app/api/"))
+
+	// file upload: POST multipart/form-data or PUT raw body -> "./uploads"
+	uploader := NewUploader(DirStorage("./uploads"))
+	uploader.SetMaxSize(10 << 20)
+	uploader.SetAllowedTypes("image/*")
+	router.POST("/upload", uploader.Handler())
+	router.PUT("/upload/:name", uploader.Handler())
+
+	// tus 1.0 resumable upload: "/files" -> "./tus"
+	tus := NewTusServer("./tus")
+	tus.Mount(router.Group("/files"))
+
+	// download as attachment with the RFC 6266 (UTF-8) filename
+	StaticFile(&router.RouterGroup, "/report", "./report.pdf", "no-cache", Download("レポート.pdf"))
+	router.GET("/export", func(c *gin.Context) {
+		ServeAttachment(c, "./export.csv", "エクスポート.csv")
+	})
+
+	// download directory as archive: "/download/docs.zip" or "/download/docs.tar.gz" -> "./share/docs"
+	archiver := NewArchiver(http.Dir("./share"))
+	archiver.SetExcludes("*.bak", "node_modules")
+	archiver.SetMaxSize(1 << 30)
+	router.GET("/download/*path", archiver.Handler())
+
+	// static serve zip archive entries: "/bundle" -> "./bundle.zip", the deflated entries are served as gzip
+	zfs, _ := OpenZipFS("./bundle.zip")
+	StaticFS(router.Group("/bundle"), "/", "", zfs, Public1Year)
+
+	// static serve overlay file system: "/theme" -> "./custom" then the embedded defaults
+	ofs := NewOverlayFS(http.Dir("./custom"), http.FS(fsdata))
+	StaticFS(router.Group("/theme"), "/", "/fsdir", ofs, "public")
+
 	server := &http.Server{
 		Addr:    "127.0.0.1:8888",
 		Handler: router,
@@ -0,0 +1,170 @@
+package ginfile
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Option the static file serving option
+type Option func(fh *fileHandler)
+
+// fileHandler a http.Handler which serves the files of the http.FileSystem like http.FileServer
+type fileHandler struct {
+	hfs       http.FileSystem
+	encodings []encoding // the precompressed encodings
+	etags     *etagCache // the content hash ETag cache
+
+	cacheControl string       // the default Cache-Control
+	cacheRules   []*CacheRule // the Cache-Control rules
+	expires      bool         // set the Expires header
+
+	noListing int                // the status code of the disabled directory listing
+	listing   *template.Template // the rich directory listing template
+
+	errorPages   map[int]string // the error page files
+	errorHandler ErrorHandler   // the error handler
+	noRoute      *gin.Engine    // the engine of the no route handlers
+
+	dotfiles bool     // allow dotfiles
+	denies   []string // the deny glob patterns
+	symlinks bool     // deny symlink escape
+
+	download     bool   // serve as attachment
+	downloadName string // the download file name
+
+	spaExcludes     []string // the SPA fallback excluded path prefixes
+	spaCacheControl string   // the Cache-Control of the SPA index file
+}
+
+func newFileHandler(hfs http.FileSystem, cacheControl string, opts ...Option) *fileHandler {
+	fh := &fileHandler{hfs: hfs, cacheControl: cacheControl}
+	for _, opt := range opts {
+		opt(fh)
+	}
+	fh.hfs = fh.newPolicyFS()
+	return fh
+}
+
+// ServeHTTP implements http.Handler
+func (fh *fileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upath := r.URL.Path
+	if !strings.HasPrefix(upath, "/") {
+		upath = "/" + upath
+		r.URL.Path = upath
+	}
+	fh.serveFile(w, r, path.Clean(upath), true)
+}
+
+// serveFile serve the file of the name.
+// If redirect is true, the ".../index.html" request is redirected to ".../",
+// the directory request without the trailing slash is redirected to the path with the trailing slash,
+// and the file request with the trailing slash is redirected to the path without the trailing slash.
+func (fh *fileHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, redirect bool) {
+	const indexPage = "/index.html"
+
+	if redirect && strings.HasSuffix(r.URL.Path, indexPage) {
+		localRedirect(w, r, "./")
+		return
+	}
+
+	f, err := fh.hfs.Open(name)
+	if err != nil {
+		fh.serveError(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	d, err := f.Stat()
+	if err != nil {
+		fh.serveError(w, r, err)
+		return
+	}
+
+	if redirect {
+		url := r.URL.Path
+		if d.IsDir() {
+			if url[len(url)-1] != '/' {
+				localRedirect(w, r, path.Base(url)+"/")
+				return
+			}
+		} else if url[len(url)-1] == '/' {
+			localRedirect(w, r, "../"+path.Base(url))
+			return
+		}
+	}
+
+	if d.IsDir() {
+		index := strings.TrimSuffix(name, "/") + indexPage
+		ff, err := fh.hfs.Open(index)
+		if err == nil {
+			defer ff.Close()
+			dd, err := ff.Stat()
+			if err == nil {
+				name, f, d = index, ff, dd
+			}
+		}
+	}
+
+	if d.IsDir() {
+		fh.serveDir(w, r, name, f)
+		return
+	}
+
+	fh.serveContent(w, r, name, f, d)
+}
+
+// serveContent serve the content of the regular file f
+func (fh *fileHandler) serveContent(w http.ResponseWriter, r *http.Request, name string, f http.File, d fs.FileInfo) {
+	w = fh.cacheWriter(w, name)
+	fh.setDownload(w, name)
+
+	if len(fh.encodings) > 0 && fh.serveEncoded(w, r, name, f, d) {
+		return
+	}
+	if fh.serveCompressed(w, r, name, f, d) {
+		return
+	}
+
+	fh.setETag(w, name, f, d)
+	http.ServeContent(w, r, d.Name(), d.ModTime(), f)
+}
+
+func dirList(w http.ResponseWriter, f http.File) {
+	dirs, err := f.Readdir(-1)
+	if err != nil {
+		http.Error(w, "Error reading directory", http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].Name() < dirs[j].Name() })
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<pre>\n")
+	for _, d := range dirs {
+		name := d.Name()
+		if d.IsDir() {
+			name += "/"
+		}
+		url := url.URL{Path: name}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", url.String(), html.EscapeString(name))
+	}
+	fmt.Fprintf(w, "</pre>\n")
+}
+
+// localRedirect gives a Moved Permanently response.
+// It does not convert relative paths to absolute paths like http.Redirect does.
+func localRedirect(w http.ResponseWriter, r *http.Request, newPath string) {
+	if q := r.URL.RawQuery; q != "" {
+		newPath += "?" + q
+	}
+	w.Header().Set("Location", newPath)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
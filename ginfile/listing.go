@@ -0,0 +1,260 @@
+package ginfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoListing disable the directory listing of the directory without the "index.html" file,
+// the status code (http.StatusNotFound or http.StatusForbidden) is returned instead.
+func NoListing(status int) Option {
+	return func(fh *fileHandler) {
+		fh.noListing = status
+	}
+}
+
+// RichListing enable the rich directory listing of the directory without the "index.html" file.
+// The listing is rendered by the html template tpl with the *DirListing data
+// (the built-in template is used if tpl is nil),
+// or is returned as JSON if the request "Accept" header prefers "application/json".
+// The entries are sorted by the query parameters "sort" ("name", "size", "time") and "order" ("asc", "desc"),
+// the directories are listed first.
+func RichListing(tpl *template.Template) Option {
+	if tpl == nil {
+		tpl = listingTemplate
+	}
+	return func(fh *fileHandler) {
+		fh.listing = tpl
+	}
+}
+
+// DirEntry a directory listing entry
+type DirEntry struct {
+	Name    string    `json:"name"`
+	URL     string    `json:"url"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	IsDir   bool      `json:"isDir"`
+}
+
+// SizeText returns the human readable size of the entry ("-" for the directory)
+func (de *DirEntry) SizeText() string {
+	if de.IsDir {
+		return "-"
+	}
+
+	const unit = 1024
+	if de.Size < unit {
+		return fmt.Sprintf("%d B", de.Size)
+	}
+	div, exp := int64(unit), 0
+	for n := de.Size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(de.Size)/float64(div), "KMGTPE"[exp])
+}
+
+// Breadcrumb a breadcrumb link of the directory listing
+type Breadcrumb struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// DirListing the directory listing data
+type DirListing struct {
+	Path        string        `json:"path"`
+	Breadcrumbs []*Breadcrumb `json:"breadcrumbs"`
+	Entries     []*DirEntry   `json:"entries"`
+	Sort        string        `json:"sort"`
+	Order       string        `json:"order"`
+}
+
+// SortURL returns the relative URL to sort the listing by the column,
+// the order is toggled if the listing is already sorted by the column.
+func (dl *DirListing) SortURL(column string) string {
+	order := "asc"
+	if dl.Sort == column && dl.Order == "asc" {
+		order = "desc"
+	}
+	return "?sort=" + url.QueryEscape(column) + "&order=" + order
+}
+
+// serveDir serve the directory listing of the directory f
+func (fh *fileHandler) serveDir(w http.ResponseWriter, r *http.Request, name string, f http.File) {
+	if fh.noListing != 0 {
+		fh.serveStatus(w, r, fh.noListing)
+		return
+	}
+
+	w = fh.cacheWriter(w, name)
+
+	if fh.listing == nil {
+		dirList(w, f)
+		return
+	}
+
+	fis, err := f.Readdir(-1)
+	if err != nil {
+		http.Error(w, "Error reading directory", http.StatusInternalServerError)
+		return
+	}
+
+	upath := publicPath(r)
+	dl := &DirListing{
+		Path:        upath,
+		Breadcrumbs: breadcrumbs(upath),
+		Sort:        r.URL.Query().Get("sort"),
+		Order:       r.URL.Query().Get("order"),
+	}
+	for _, fi := range fis {
+		de := &DirEntry{
+			Name:    fi.Name(),
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+			IsDir:   fi.IsDir(),
+		}
+		u := url.URL{Path: de.Name}
+		de.URL = u.String()
+		if de.IsDir {
+			de.URL += "/"
+		}
+		dl.Entries = append(dl.Entries, de)
+	}
+	sortEntries(dl)
+
+	if acceptJSON(r) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(dl) //nolint: errcheck
+		return
+	}
+
+	bb := &bytes.Buffer{}
+	if err := fh.listing.Execute(bb, dl); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(bb.Bytes()) //nolint: errcheck
+}
+
+// sortEntries sort the entries by the listing Sort and Order, the directories are listed first
+func sortEntries(dl *DirListing) {
+	switch dl.Sort {
+	case "name", "size", "time":
+	default:
+		dl.Sort = "name"
+	}
+	if dl.Order != "desc" {
+		dl.Order = "asc"
+	}
+
+	des := dl.Entries
+	sort.SliceStable(des, func(i, j int) bool {
+		a, b := des[i], des[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		if dl.Order == "desc" {
+			a, b = b, a
+		}
+		switch dl.Sort {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case "time":
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.Before(b.ModTime)
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// publicPath returns the public URL path of the request in the mounted route (the "*path" parameter),
+// the rewritten URL path of the request may contain the local path of the file system.
+func publicPath(r *http.Request) string {
+	if c, ok := r.Context().Value(ginContextKey{}).(*gin.Context); ok {
+		if p := c.Param("path"); p != "" {
+			return cleanPath(p)
+		}
+	}
+	return r.URL.Path
+}
+
+// breadcrumbs returns the relative breadcrumb links of the directory URL path
+func breadcrumbs(upath string) []*Breadcrumb {
+	names := strings.Split(strings.Trim(upath, "/"), "/")
+	if len(names) == 1 && names[0] == "" {
+		names = nil
+	}
+
+	bcs := []*Breadcrumb{{Name: "/", URL: strings.Repeat("../", len(names))}}
+	for i, name := range names {
+		bcs = append(bcs, &Breadcrumb{Name: name, URL: strings.Repeat("../", len(names)-i-1)})
+	}
+	for _, bc := range bcs {
+		if bc.URL == "" {
+			bc.URL = "./"
+		}
+	}
+	return bcs
+}
+
+// acceptJSON returns true if the request "Accept" header prefers "application/json" to "text/html"
+func acceptJSON(r *http.Request) bool {
+	jq, hq := 0.0, 0.0
+	for _, a := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, q := parseQuality(a)
+		switch {
+		case strings.EqualFold(mt, "application/json"):
+			jq = q
+		case strings.EqualFold(mt, "text/html"):
+			hq = q
+		}
+	}
+	return jq > 0 && jq > hq
+}
+
+// listingTemplate the built-in rich directory listing template
+var listingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Index of {{.Path}}</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; margin: 1em; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 16px 4px 0; text-align: left; }
+td.size { text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{range $i, $b := .Breadcrumbs}}{{if $i}} / {{end}}<a href="{{$b.URL}}">{{$b.Name}}</a>{{end}}</h1>
+<table>
+<tr>
+	<th><a href="{{.SortURL "name"}}">Name</a></th>
+	<th><a href="{{.SortURL "size"}}">Size</a></th>
+	<th><a href="{{.SortURL "time"}}">Modified</a></th>
+</tr>
+{{range .Entries}}
+<tr>
+	<td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td>
+	<td class="size">{{.SizeText}}</td>
+	<td>{{.ModTime.Format "2006-01-02 15:04:05"}}</td>
+</tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,150 @@
+package ginfile
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNoListing(t *testing.T) {
+	r := gin.New()
+	Static(r.Group("/nf"), "/", "testdata", "", NoListing(http.StatusNotFound))
+	Static(r.Group("/fb"), "/", "testdata", "", NoListing(http.StatusForbidden))
+
+	cs := []struct {
+		path string
+		code int
+	}{
+		{"/nf/", 404},
+		{"/nf/d1/", 404},
+		{"/fb/d1/", 403},
+		{"/nf/d1/d1f1.txt", 200},
+	}
+	for i, c := range cs {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", c.path, nil))
+		if w.Code != c.code {
+			t.Errorf("[%d] %s code = %d, want %d", i, c.path, w.Code, c.code)
+		}
+	}
+}
+
+func TestRichListing(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"a/b/big.txt":   []byte(strings.Repeat("x", 2048)),
+		"a/b/small.txt": []byte("x"),
+		"a/b/c/x.txt":   []byte("x"),
+		"a/b/<i>.txt":   []byte("xy"),
+	})
+
+	r := gin.New()
+	Static(&r.RouterGroup, "/", dir, "", RichListing(nil))
+
+	// html
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/a/b/", nil)
+	req.Header.Set("Accept", "text/html,*/*;q=0.8")
+	r.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("code = %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, s := range []string{
+		`<a href="../../">/</a> / <a href="../">a</a> / <a href="./">b</a>`,
+		`<a href="c/">c/</a>`,
+		`<a href="big.txt">big.txt</a>`,
+		`2.0 KB`,
+		`&lt;i&gt;.txt`,
+		`<a href="?sort=name&amp;order=desc">Name</a>`,
+		`<a href="?sort=size&amp;order=asc">Size</a>`,
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("listing does not contain %q\n%s", s, body)
+		}
+	}
+
+	// json
+	testJSON := func(query string, want ...string) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/a/b/"+query, nil)
+		req.Header.Set("Accept", "application/json")
+		r.ServeHTTP(w, req)
+
+		dl := &DirListing{}
+		if err := json.Unmarshal(w.Body.Bytes(), dl); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		names := []string{}
+		for _, de := range dl.Entries {
+			names = append(names, de.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("%s entries = %v, want %v", query, names, want)
+		}
+	}
+
+	testJSON("", "c", "<i>.txt", "big.txt", "small.txt")
+	testJSON("?sort=name&order=desc", "c", "small.txt", "big.txt", "<i>.txt")
+	testJSON("?sort=size", "c", "small.txt", "<i>.txt", "big.txt")
+	testJSON("?sort=size&order=desc", "c", "big.txt", "<i>.txt", "small.txt")
+}
+
+func TestRichListingPublicPath(t *testing.T) {
+	rg := gin.New()
+	StaticFS(rg.Group("/files"), "/", "/testdata", http.FS(testdata), "", RichListing(nil))
+	rr := gin.New()
+	StaticFS(&rr.RouterGroup, "/", "/testdata", http.FS(testdata), "", RichListing(nil))
+
+	for target, r := range map[string]*gin.Engine{"/files/d1/": rg, "/d1/": rr} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", "application/json")
+		r.ServeHTTP(w, req)
+
+		dl := &DirListing{}
+		if err := json.Unmarshal(w.Body.Bytes(), dl); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if dl.Path != "/d1/" || len(dl.Breadcrumbs) != 2 || dl.Breadcrumbs[0].URL != "../" {
+			t.Errorf("%s: Path = %q, Breadcrumbs = %v", target, dl.Path, dl.Breadcrumbs)
+		}
+		if strings.Contains(w.Body.String(), "testdata") {
+			t.Errorf("%s: listing contains the local path: %s", target, w.Body.String())
+		}
+	}
+}
+
+func TestRichListingTemplate(t *testing.T) {
+	tpl := template.Must(template.New("").Parse(`{{range .Entries}}[{{.Name}}]{{end}}`))
+
+	r := gin.New()
+	Static(&r.RouterGroup, "/", "testdata", "", RichListing(tpl))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Body.String() != "[d1][r1.txt][testdata_test.go]" {
+		t.Errorf("body = %q", w.Body.String())
+	}
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	cs := map[string]string{
+		"/":     "/=./",
+		"/a/":   "/=../ a=./",
+		"/a/b/": "/=../../ a=../ b=./",
+	}
+	for p, want := range cs {
+		ss := []string{}
+		for _, bc := range breadcrumbs(p) {
+			ss = append(ss, bc.Name+"="+bc.URL)
+		}
+		if a := strings.Join(ss, " "); a != want {
+			t.Errorf("breadcrumbs(%q) = %q, want %q", p, a, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package ginfile
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+)
+
+// OverlayFS a http.FileSystem which searches the file in the ordered layers of the file systems.
+// The file of the upper (former) layer overrides the file of the same name in the lower (latter) layers.
+// The directory listings of the same directory in all layers are merged,
+// the entry of the upper layer wins if the names are same.
+// A regular file in the upper layer hides the directory of the same name in the lower layers.
+//
+//	ofs := ginfile.NewOverlayFS(http.Dir("./custom"), http.FS(defaults))
+//	ginfile.StaticFS(g, "/theme", "/", ofs, "public")
+type OverlayFS struct {
+	layers []http.FileSystem
+}
+
+// NewOverlayFS create a overlay file system of the layers, the first layer is the top layer.
+func NewOverlayFS(layers ...http.FileSystem) *OverlayFS {
+	return &OverlayFS{layers: layers}
+}
+
+// Open implements http.FileSystem
+func (ofs *OverlayFS) Open(name string) (http.File, error) {
+	name = path.Clean("/" + name)
+
+	var dirs []http.File
+	var ferr error
+	for _, hfs := range ofs.layers {
+		f, err := hfs.Open(name)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) && ferr == nil {
+				ferr = err
+			}
+			continue
+		}
+
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			if ferr == nil {
+				ferr = err
+			}
+			continue
+		}
+
+		if fi.IsDir() {
+			dirs = append(dirs, f)
+			continue
+		}
+
+		if len(dirs) > 0 {
+			// the directory of the upper layer hides the file of the lower layer
+			f.Close()
+			continue
+		}
+		return f, nil
+	}
+
+	if len(dirs) == 1 {
+		return dirs[0], nil
+	}
+	if len(dirs) > 1 {
+		return &overlayDir{File: dirs[0], dirs: dirs}, nil
+	}
+	if ferr != nil {
+		return nil, ferr
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// overlayDir a http.File of the directory which exists in multiple layers.
+// The embedded http.File is the directory of the top layer.
+type overlayDir struct {
+	http.File
+	dirs    []http.File
+	entries []fs.FileInfo // the merged entries (nil: not loaded)
+	offset  int           // the Readdir offset
+}
+
+// Close implements http.File
+func (od *overlayDir) Close() error {
+	var err error
+	for _, d := range od.dirs {
+		if e := d.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Readdir implements http.File
+func (od *overlayDir) Readdir(count int) ([]fs.FileInfo, error) {
+	if od.entries == nil {
+		if err := od.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	ents := od.entries[od.offset:]
+	if count > 0 {
+		if len(ents) == 0 {
+			return nil, io.EOF
+		}
+		if count < len(ents) {
+			ents = ents[:count]
+		}
+	}
+	od.offset += len(ents)
+
+	fis := make([]fs.FileInfo, len(ents))
+	copy(fis, ents)
+	return fis, nil
+}
+
+// load read and merge the entries of the directories
+func (od *overlayDir) load() error {
+	names := make(map[string]bool)
+	ents := []fs.FileInfo{}
+
+	for _, d := range od.dirs {
+		fis, err := d.Readdir(-1)
+		if err != nil {
+			return err
+		}
+		for _, fi := range fis {
+			if !names[fi.Name()] {
+				names[fi.Name()] = true
+				ents = append(ents, fi)
+			}
+		}
+	}
+
+	sort.Slice(ents, func(i, j int) bool { return ents[i].Name() < ents[j].Name() })
+	od.entries = ents
+	return nil
+}
@@ -0,0 +1,113 @@
+package ginfile
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestOverlayFS(t *testing.T) *OverlayFS {
+	custom := writeTestFiles(t, map[string][]byte{
+		"logo.png":     []byte("custom logo"),
+		"css/site.css": []byte("custom css"),
+		"js":           []byte("custom js file"),
+	})
+
+	defaults := fstest.MapFS{
+		"logo.png":      {Data: []byte("default logo")},
+		"favicon.ico":   {Data: []byte("default favicon")},
+		"css/site.css":  {Data: []byte("default css")},
+		"css/reset.css": {Data: []byte("default reset")},
+		"js/app.js":     {Data: []byte("default app")},
+	}
+
+	return NewOverlayFS(http.Dir(custom), http.FS(defaults))
+}
+
+func TestOverlayFSOpen(t *testing.T) {
+	ofs := newTestOverlayFS(t)
+
+	cs := map[string]string{
+		"/logo.png":      "custom logo",
+		"/favicon.ico":   "default favicon",
+		"/css/site.css":  "custom css",
+		"/css/reset.css": "default reset",
+		"/js":            "custom js file",
+	}
+	for name, want := range cs {
+		f, err := ofs.Open(name)
+		if err != nil {
+			t.Errorf("Open(%q) = %v", name, err)
+			continue
+		}
+		bs, _ := io.ReadAll(f)
+		f.Close()
+		if string(bs) != want {
+			t.Errorf("Open(%q) = %q, want %q", name, bs, want)
+		}
+	}
+
+	for _, name := range []string{"/none", "/css/none.css"} {
+		if _, err := ofs.Open(name); !os.IsNotExist(err) {
+			t.Errorf("Open(%q) = %v, want not exist", name, err)
+		}
+	}
+
+	f, err := ofs.Open("/css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fis, err := f.Readdir(1)
+	if err != nil || len(fis) != 1 || fis[0].Name() != "reset.css" {
+		t.Fatalf("Readdir(1) = %v, %v", fis, err)
+	}
+	fis, err = f.Readdir(-1)
+	if err != nil || len(fis) != 1 || fis[0].Name() != "site.css" || fis[0].Size() != int64(len("custom css")) {
+		t.Fatalf("Readdir(-1) = %v, %v", fis, err)
+	}
+	if _, err := f.Readdir(1); err != io.EOF {
+		t.Errorf("Readdir(1) = %v, want EOF", err)
+	}
+}
+
+func TestOverlayFSStatic(t *testing.T) {
+	ofs := newTestOverlayFS(t)
+
+	r := gin.New()
+	StaticFS(r.Group("/theme"), "/", "/", ofs, "", RichListing(nil))
+
+	w := testGetCode(t, r, "/theme/logo.png", http.StatusOK)
+	if a := w.Body.String(); a != "custom logo" {
+		t.Errorf("body = %q", a)
+	}
+	w = testGetCode(t, r, "/theme/css/reset.css", http.StatusOK)
+	if a := w.Body.String(); a != "default reset" {
+		t.Errorf("body = %q", a)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/theme/", nil)
+	req.Header.Set("Accept", "application/json")
+	r.ServeHTTP(w, req)
+
+	dl := map[string]interface{}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &dl); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, e := range dl["entries"].([]interface{}) {
+		names = append(names, e.(map[string]interface{})["name"].(string))
+	}
+	if a, want := strings.Join(names, ","), "css,favicon.ico,js,logo.png"; a != want {
+		t.Errorf("listing = %q, want %q", a, want)
+	}
+}
@@ -0,0 +1,211 @@
+package ginfile
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// AllowDotfiles allow to serve the dotfiles (e.g. ".env", ".git/config").
+// By default, the files and directories which name begins with "." are not served
+// (except the ".well-known" directory) and are hidden from the directory listing.
+func AllowDotfiles() Option {
+	return func(fh *fileHandler) {
+		fh.dotfiles = true
+	}
+}
+
+// Deny deny to serve the files which match the glob patterns (see path.Match).
+// If the pattern contains "/", the pattern is matched with the full file name of the http.FileSystem,
+// otherwise the pattern is matched with each element of the file name (e.g. "*.bak", "node_modules").
+// The denied files are responded as "404 Not Found" and are hidden from the directory listing.
+// Panic if the pattern is malformed.
+func Deny(patterns ...string) Option {
+	checkGlobs(patterns)
+	return func(fh *fileHandler) {
+		fh.denies = append(fh.denies, patterns...)
+	}
+}
+
+// DenySymlinkEscape deny to serve the files which real path (the symbolic links are evaluated)
+// is out of the root directory.
+// It only works with the http.Dir file system.
+func DenySymlinkEscape() Option {
+	return func(fh *fileHandler) {
+		fh.symlinks = true
+	}
+}
+
+// checkGlobs panic if a glob pattern is malformed
+func checkGlobs(patterns []string) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			panic("ginfile: invalid glob pattern " + strconv.Quote(p) + ": " + err.Error())
+		}
+	}
+}
+
+// matchGlob reports whether the file name matches the glob pattern.
+// If the pattern contains "/", the pattern is matched with the full file name,
+// otherwise the pattern is matched with the base name of the file.
+func matchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// cleanPath returns the cleaned path with the leading slash,
+// the trailing slash is kept.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
+
+// policyFS a http.FileSystem which denies the files by the security policy
+type policyFS struct {
+	hfs      http.FileSystem
+	dotfiles bool     // allow dotfiles
+	denies   []string // deny glob patterns
+	root     string   // the real path of the http.Dir root (deny symlink escape)
+}
+
+// newPolicyFS returns the security policy file system of the file handler
+func (fh *fileHandler) newPolicyFS() http.FileSystem {
+	pfs := &policyFS{hfs: fh.hfs, dotfiles: fh.dotfiles, denies: fh.denies}
+
+	if fh.symlinks {
+		if dir, ok := fh.hfs.(http.Dir); ok {
+			root := string(dir)
+			if root == "" {
+				root = "."
+			}
+			if abs, err := filepath.Abs(root); err == nil {
+				root = abs
+			}
+			if real, err := filepath.EvalSymlinks(root); err == nil {
+				root = real
+			}
+			pfs.root = root
+		}
+	}
+
+	if pfs.dotfiles && len(pfs.denies) == 0 && pfs.root == "" {
+		return fh.hfs
+	}
+	return pfs
+}
+
+// Open implements http.FileSystem
+func (pfs *policyFS) Open(name string) (http.File, error) {
+	name = path.Clean("/" + name)
+	if pfs.denied(name) || pfs.escaped(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	f, err := pfs.hfs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &policyFile{File: f, pfs: pfs, name: name}, nil
+}
+
+// denied returns true if the file name is denied by the dotfiles or the deny patterns
+func (pfs *policyFS) denied(name string) bool {
+	if name == "/" {
+		return false
+	}
+
+	elems := strings.Split(name[1:], "/")
+	if !pfs.dotfiles {
+		for _, e := range elems {
+			if e != "" && e[0] == '.' && e != ".well-known" {
+				return true
+			}
+		}
+	}
+
+	for _, p := range pfs.denies {
+		if strings.Contains(p, "/") {
+			if matchGlob(p, name) {
+				return true
+			}
+			continue
+		}
+		for _, e := range elems {
+			if matchGlob(p, e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// escaped returns true if the real path of the file name is out of the root directory
+func (pfs *policyFS) escaped(name string) bool {
+	if pfs.root == "" {
+		return false
+	}
+
+	real, err := filepath.EvalSymlinks(filepath.Join(pfs.root, filepath.FromSlash(name)))
+	if err != nil {
+		// not exist or broken link, let the underlying file system to report the error
+		return !os.IsNotExist(err)
+	}
+	return real != pfs.root && !strings.HasPrefix(real, pfs.root+string(filepath.Separator))
+}
+
+// policyFile a http.File which hides the denied files from the directory listing
+type policyFile struct {
+	http.File
+	pfs  *policyFS
+	name string
+}
+
+// Readdir implements http.File
+func (pf *policyFile) Readdir(count int) ([]fs.FileInfo, error) {
+	if count <= 0 {
+		fis, err := pf.File.Readdir(count)
+		return pf.filter(fis), err
+	}
+
+	fis := make([]fs.FileInfo, 0, count)
+	for len(fis) < count {
+		rs, err := pf.File.Readdir(count - len(fis))
+		fis = append(fis, pf.filter(rs)...)
+		if err != nil {
+			if len(fis) > 0 && err == io.EOF { //nolint: errorlint
+				err = nil
+			}
+			return fis, err
+		}
+	}
+	return fis, nil
+}
+
+func (pf *policyFile) filter(fis []fs.FileInfo) []fs.FileInfo {
+	n := 0
+	for _, fi := range fis {
+		name := path.Join(pf.name, fi.Name())
+		if !pf.pfs.denied(name) && !pf.pfs.escaped(name) {
+			fis[n] = fi
+			n++
+		}
+	}
+	return fis[:n]
+}
@@ -0,0 +1,182 @@
+package ginfile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testGetCode(t *testing.T, r http.Handler, target string, code int) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	if w.Code != code {
+		t.Errorf("%s code = %d, want %d", target, w.Code, code)
+	}
+	return w
+}
+
+func TestCleanPath(t *testing.T) {
+	cs := map[string]string{
+		"":            "/",
+		"a":           "/a",
+		"/a/../b/":    "/b/",
+		"/../../x":    "/x",
+		"/a/./b/..":   "/a",
+		"/../":        "/",
+		"a\\..\\..\\": "/a\\..\\..\\",
+	}
+	for p, want := range cs {
+		if a := cleanPath(p); a != want {
+			t.Errorf("cleanPath(%q) = %q, want %q", p, a, want)
+		}
+	}
+}
+
+func TestDotfiles(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		".env":                     []byte("SECRET=1"),
+		".git/config":              []byte("[core]"),
+		"a/.hidden.txt":            []byte("hidden"),
+		"a/b.txt":                  []byte("b.txt"),
+		".well-known/security.txt": []byte("security.txt"),
+	})
+
+	r := gin.New()
+	Static(r.Group("/deny"), "/", dir, "")
+	Static(r.Group("/allow"), "/", dir, "", AllowDotfiles())
+
+	testGetCode(t, r, "/deny/.env", 404)
+	testGetCode(t, r, "/deny/.git/config", 404)
+	testGetCode(t, r, "/deny/.git/", 404)
+	testGetCode(t, r, "/deny/a/.hidden.txt", 404)
+	testGetCode(t, r, "/deny/a/b.txt", 200)
+	testGetCode(t, r, "/deny/.well-known/security.txt", 200)
+
+	w := testGetCode(t, r, "/deny/a/", 200)
+	if strings.Contains(w.Body.String(), ".hidden") {
+		t.Errorf("listing contains the dotfile: %s", w.Body.String())
+	}
+
+	// the explicitly registered single file is served
+	StaticFile(r.Group("/single"), "/h", filepath.Join(dir, "a", ".hidden.txt"), "")
+	StaticFSFile(r.Group("/single"), "/env", ".env", http.Dir(dir), "", Deny(".env"))
+	testGetCode(t, r, "/single/h", 200)
+	testGetCode(t, r, "/single/env", 200)
+
+	testGetCode(t, r, "/allow/.env", 200)
+	testGetCode(t, r, "/allow/.git/config", 200)
+	w = testGetCode(t, r, "/allow/a/", 200)
+	if !strings.Contains(w.Body.String(), ".hidden.txt") {
+		t.Errorf("listing does not contain the dotfile: %s", w.Body.String())
+	}
+}
+
+func TestDeny(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"a.txt":               []byte("a.txt"),
+		"a.txt.bak":           []byte("a.txt.bak"),
+		"node_modules/x.js":   []byte("x.js"),
+		"conf/app.yaml":       []byte("app.yaml"),
+		"conf/public/app.css": []byte("app.css"),
+	})
+
+	r := gin.New()
+	Static(&r.RouterGroup, "/", dir, "", Deny("*.bak", "node_modules", "/conf/*.yaml"))
+
+	testGetCode(t, r, "/a.txt", 200)
+	testGetCode(t, r, "/a.txt.bak", 404)
+	testGetCode(t, r, "/node_modules/x.js", 404)
+	testGetCode(t, r, "/conf/app.yaml", 404)
+	testGetCode(t, r, "/conf/public/app.css", 200)
+
+	w := testGetCode(t, r, "/", 200)
+	if strings.Contains(w.Body.String(), ".bak") || strings.Contains(w.Body.String(), "node_modules") {
+		t.Errorf("listing contains the denied files: %s", w.Body.String())
+	}
+}
+
+func TestTraversal(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"secret.txt":        []byte("secret"),
+		"public/index.html": []byte("index"),
+		"public/a.txt":      []byte("a.txt"),
+	})
+
+	targets := []string{
+		"/%2e%2e/secret.txt",
+		"/%2E%2E/secret.txt",
+		"/..%2fsecret.txt",
+		"/%2e%2e%2fsecret.txt",
+		"/a/../../secret.txt",
+		"/..\\secret.txt",
+		"/%5c..%5csecret.txt",
+		"/.%2e/.%2e/secret.txt",
+	}
+
+	// url replace
+	r1 := gin.New()
+	StaticFS(&r1.RouterGroup, "/data", "/public", http.Dir(dir), "")
+	testGetCode(t, r1, "/data/a.txt", 200)
+
+	// append prefix
+	r2 := gin.New()
+	StaticFS(&r2.RouterGroup, "/", "/public", http.Dir(dir), "")
+	testGetCode(t, r2, "/a.txt", 200)
+
+	// strip prefix
+	r3 := gin.New()
+	Static(r3.Group("/data"), "/", filepath.Join(dir, "public"), "")
+	testGetCode(t, r3, "/data/a.txt", 200)
+
+	for _, target := range targets {
+		for _, c := range []struct {
+			r      *gin.Engine
+			prefix string
+		}{{r1, "/data"}, {r2, ""}, {r3, "/data"}} {
+			w := httptest.NewRecorder()
+			c.r.ServeHTTP(w, httptest.NewRequest("GET", c.prefix+target, nil))
+			if strings.Contains(w.Body.String(), "secret") {
+				t.Errorf("%s%s escaped: %d %q", c.prefix, target, w.Code, w.Body.String())
+			}
+		}
+	}
+}
+
+func TestDenySymlinkEscape(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"secret.txt":     []byte("secret"),
+		"public/a.txt":   []byte("a.txt"),
+		"public/d/b.txt": []byte("b.txt"),
+	})
+	pub := filepath.Join(dir, "public")
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(pub, "secret.txt")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(pub, "parent")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink(filepath.Join(pub, "a.txt"), filepath.Join(pub, "d", "a.txt")); err != nil {
+		t.Skip(err)
+	}
+
+	r := gin.New()
+	Static(r.Group("/deny"), "/", pub, "", DenySymlinkEscape())
+	Static(r.Group("/allow"), "/", pub, "")
+
+	testGetCode(t, r, "/deny/a.txt", 200)
+	testGetCode(t, r, "/deny/d/a.txt", 200)
+	testGetCode(t, r, "/deny/secret.txt", 404)
+	testGetCode(t, r, "/deny/parent/secret.txt", 404)
+	testGetCode(t, r, "/deny/parent/", 404)
+	testGetCode(t, r, "/allow/secret.txt", 200)
+
+	w := testGetCode(t, r, "/deny/", 200)
+	if strings.Contains(w.Body.String(), "secret") || strings.Contains(w.Body.String(), "parent") {
+		t.Errorf("listing contains the escaped links: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,112 @@
+package ginfile
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SPAExclude the request URL paths with the prefixes (e.g. "/app/api/") are not fallen back to the index file
+// by StaticSPA(), a "404 Not Found" error is returned if the file does not exist.
+func SPAExclude(prefixes ...string) Option {
+	return func(fh *fileHandler) {
+		fh.spaExcludes = prefixes
+	}
+}
+
+// SPAIndexCacheControl set the Cache-Control header of the index file served by StaticSPA().
+// Default: "no-cache"
+func SPAIndexCacheControl(cacheControl string) Option {
+	return func(fh *fileHandler) {
+		fh.spaCacheControl = cacheControl
+	}
+}
+
+// StaticSPA registers the routes in order to serve a single page application (history API mode).
+// The existing files of the hfs are served with the cacheControl header,
+// all other paths are fallen back to the indexFile with the "no-cache" Cache-Control header
+// (see SPAIndexCacheControl), except the paths excluded by SPAExclude and
+// the requests which "Accept" header does not accept "text/html"
+// (the wildcard "*/*" accepts "text/html" only for the paths without a file extension).
+// ginfile.StaticSPA(router.Group("/app"), "/", http.Dir("./dist"), "index.html", ginfile.Public1Year, ginfile.SPAExclude("/app/api/"))
+func StaticSPA(g *gin.RouterGroup, relativePath string, hfs http.FileSystem, indexFile string, cacheControl string, opts ...Option) {
+	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
+		panic("URL parameters can not be used when serving a static folder")
+	}
+
+	fh := newFileHandler(hfs, cacheControl, append([]Option{SPAIndexCacheControl("no-cache")}, opts...)...)
+	index := path.Clean("/" + indexFile)
+
+	handler := func(c *gin.Context) {
+		name := path.Clean("/" + c.Param("path"))
+		if name != "/" && name != index && fh.isFile(name) {
+			fh.serveFile(c.Writer, fh.request(c), name, false)
+			return
+		}
+
+		if name != "/" && name != index && (fh.spaExcluded(c.Request.URL.Path) || !acceptHTML(c.Request)) {
+			fh.serveStatus(c.Writer, fh.request(c), http.StatusNotFound)
+			return
+		}
+
+		if fh.spaCacheControl != "" {
+			c.Header("Cache-Control", fh.spaCacheControl)
+		}
+		fh.serveFile(c.Writer, fh.request(c), index, false)
+	}
+
+	urlPattern := path.Join(relativePath, "/*path")
+
+	g.GET(urlPattern, handler)
+	g.HEAD(urlPattern, handler)
+}
+
+// isFile returns true if the name is a regular file of the file system
+func (fh *fileHandler) isFile(name string) bool {
+	f, err := fh.hfs.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	d, err := f.Stat()
+	return err == nil && !d.IsDir()
+}
+
+func (fh *fileHandler) spaExcluded(upath string) bool {
+	for _, p := range fh.spaExcludes {
+		if strings.HasPrefix(upath, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptHTML returns true if the request "Accept" header accepts "text/html".
+// The wildcard "*/*" or "text/*" (e.g. the default of fetch() and curl) accepts "text/html"
+// only if the last element of the URL path has no file extension,
+// so the missing asset files (e.g. "/app/missing.js") are not fallen back to the index file.
+// The explicit "text/html;q=0" does not accept "text/html".
+func acceptHTML(r *http.Request) bool {
+	hq, wq := -1.0, 0.0
+	for _, a := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, q := parseQuality(a)
+		switch {
+		case strings.EqualFold(mt, "text/html") || strings.EqualFold(mt, "application/xhtml+xml"):
+			if q > hq {
+				hq = q
+			}
+		case mt == "*/*" || strings.EqualFold(mt, "text/*"):
+			if q > wq {
+				wq = q
+			}
+		}
+	}
+
+	if hq >= 0 {
+		return hq > 0
+	}
+	return wq > 0 && path.Ext(r.URL.Path) == ""
+}
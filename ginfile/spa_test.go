@@ -0,0 +1,78 @@
+package ginfile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStaticSPA(t *testing.T) {
+	dir := writeTestFiles(t, map[string][]byte{
+		"index.html":         []byte("index.html"),
+		"assets/app.1a2b.js": []byte("app.1a2b.js"),
+	})
+
+	r := gin.New()
+	StaticSPA(r.Group("/app"), "/", http.Dir(dir), "index.html", Public1Year, SPAExclude("/app/api/"))
+
+	const html = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	cs := []struct {
+		path   string
+		accept string
+		code   int
+		cache  string
+		body   string
+	}{
+		{"/app/", html, 200, "no-cache", "index.html"},
+		{"/app/", "", 200, "no-cache", "index.html"},
+		{"/app/index.html", html, 200, "no-cache", "index.html"},
+		{"/app/assets/app.1a2b.js", "*/*", 200, Public1Year, "app.1a2b.js"},
+		{"/app/users/123", html, 200, "no-cache", "index.html"},
+		{"/app/users/123/", html, 200, "no-cache", "index.html"},
+		{"/app/assets", html, 200, "no-cache", "index.html"},
+		{"/app/assets/missing.js", "*/*", 404, "", "404 page not found\n"},
+		{"/app/users/123", "application/json", 404, "", "404 page not found\n"},
+		{"/app/users/123", "text/html;q=0", 404, "", "404 page not found\n"},
+		{"/app/users/123", "*/*", 200, "no-cache", "index.html"},
+		{"/app/users/123", "text/*;q=0.5", 200, "no-cache", "index.html"},
+		{"/app/users/123", "text/html;q=0, */*", 404, "", "404 page not found\n"},
+		{"/app/users/123", "*/*;q=0", 404, "", "404 page not found\n"},
+		{"/app/api/users", html, 404, "", "404 page not found\n"},
+	}
+
+	for i, c := range cs {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		r.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("[%d] %s code = %d, want %d", i, c.path, w.Code, c.code)
+		}
+		if a := w.Header().Get("Cache-Control"); a != c.cache {
+			t.Errorf("[%d] %s Cache-Control = %q, want %q", i, c.path, a, c.cache)
+		}
+		if a := w.Body.String(); a != c.body {
+			t.Errorf("[%d] %s body = %q, want %q", i, c.path, a, c.body)
+		}
+	}
+}
+
+func TestStaticSPAIndexCacheControl(t *testing.T) {
+	r := gin.New()
+	StaticSPA(&r.RouterGroup, "/", http.FS(testdata), "testdata/r1.txt", "private", SPAIndexCacheControl("no-store"))
+
+	testGetFile(t, r, "/testdata/d1/d1f1.txt", "private")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/unknown", nil)
+	req.Header.Set("Accept", "text/html")
+	r.ServeHTTP(w, req)
+	if w.Code != 200 || w.Body.String() != "r1.txt" || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("fallback = %d %q %q", w.Code, w.Header().Get("Cache-Control"), w.Body.String())
+	}
+}
@@ -0,0 +1,505 @@
+package ginfile
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TusVersion the supported tus protocol version
+const TusVersion = "1.0.0"
+
+// TusUpload a tus resumable upload
+type TusUpload struct {
+	ID       string            `json:"id"`
+	Length   int64             `json:"length"`
+	Offset   int64             `json:"-"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	RawMeta  string            `json:"rawMeta,omitempty"`
+	Created  time.Time         `json:"created"`
+	Expires  time.Time         `json:"expires"`
+}
+
+// Completed returns true if all the content of the upload is received
+func (tu *TusUpload) Completed() bool {
+	return tu.Offset >= tu.Length
+}
+
+var errTusNotFound = errors.New("ginfile: tus upload not found")
+
+// TusServer a tus 1.0 (https://tus.io/protocols/resumable-upload) resumable upload server
+// which stores the uploads to the local directory.
+// The core protocol and the creation, termination and expiration extensions are supported.
+// The content of the upload "{id}" is stored to the file "{dir}/{id}",
+// the upload information is stored to the file "{dir}/{id}.info".
+// The expired incomplete uploads are removed by Clean(), which is called periodically
+// (at most once per minute) when a upload is created.
+//
+//	ts := ginfile.NewTusServer("./uploads")
+//	ts.SetMaxSize(1 << 30)
+//	ts.SetCompleteHandler(func(c *gin.Context, tu *ginfile.TusUpload) {
+//		os.Rename(ts.FilePath(tu.ID), "./files/"+tu.ID)
+//	})
+//	ts.Mount(router.Group("/files"))
+type TusServer struct {
+	dir        string
+	maxSize    int64
+	expiration time.Duration
+	onComplete func(c *gin.Context, tu *TusUpload)
+
+	// cleanInterval the minimum interval of the expired uploads cleaning
+	cleanInterval time.Duration
+
+	// lastClean the unix nano time of the last expired uploads cleaning
+	lastClean int64
+
+	mutex sync.Mutex
+	locks map[string]bool // the uploads which are being patched
+}
+
+// NewTusServer create a tus server which stores the uploads to the directory dir
+func NewTusServer(dir string) *TusServer {
+	return &TusServer{
+		dir:           dir,
+		expiration:    time.Hour * 24,
+		cleanInterval: time.Minute,
+		locks:         make(map[string]bool),
+	}
+}
+
+// SetMaxSize set the max size of a upload.
+// Default: 0 (unlimited)
+func (ts *TusServer) SetMaxSize(maxSize int64) {
+	ts.maxSize = maxSize
+}
+
+// SetExpiration set the expiration of the incomplete uploads,
+// the expiration is extended when the content of the upload is received.
+// Default: 24 hours
+func (ts *TusServer) SetExpiration(expiration time.Duration) {
+	ts.expiration = expiration
+}
+
+// SetCompleteHandler set the handler which is called when all the content of the upload is received
+// (by the completing PATCH request, or by the POST request of the zero length upload).
+// The "204 No Content" response is not written if the handler aborts the context.
+func (ts *TusServer) SetCompleteHandler(handler func(c *gin.Context, tu *TusUpload)) {
+	ts.onComplete = handler
+}
+
+// Mount mount the tus routes to the router group.
+//
+//	OPTIONS /      the server capabilities
+//	POST    /      create a upload
+//	HEAD    /:id   get the upload offset
+//	PATCH   /:id   append the content to the upload
+//	DELETE  /:id   terminate the upload
+func (ts *TusServer) Mount(g *gin.RouterGroup) {
+	base := strings.TrimSuffix(g.BasePath(), "/")
+
+	g.OPTIONS("/", ts.options)
+	g.POST("/", func(c *gin.Context) {
+		ts.create(c, base)
+	})
+	g.OPTIONS("/:id", ts.options)
+	g.HEAD("/:id", ts.head)
+	g.PATCH("/:id", ts.patch)
+	g.DELETE("/:id", ts.delete)
+}
+
+// FilePath returns the content file path of the upload
+func (ts *TusServer) FilePath(id string) string {
+	return filepath.Join(ts.dir, id)
+}
+
+// Get returns the upload of the id
+func (ts *TusServer) Get(id string) (*TusUpload, error) {
+	if !isUploadID(id) {
+		return nil, errTusNotFound
+	}
+
+	data, err := os.ReadFile(ts.FilePath(id) + ".info")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errTusNotFound
+		}
+		return nil, err
+	}
+
+	tu := &TusUpload{}
+	if err := json.Unmarshal(data, tu); err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(ts.FilePath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errTusNotFound
+		}
+		return nil, err
+	}
+	tu.Offset = fi.Size()
+	return tu, nil
+}
+
+// Clean remove the expired incomplete uploads.
+// It's called periodically by the upload creation.
+func (ts *TusServer) Clean() error {
+	des, err := os.ReadDir(ts.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+	for _, de := range des {
+		id := strings.TrimSuffix(de.Name(), ".info")
+		if id == de.Name() || !isUploadID(id) {
+			continue
+		}
+
+		tu, err := ts.Get(id)
+		if errors.Is(err, errTusNotFound) {
+			// orphan info file
+			os.Remove(ts.FilePath(id) + ".info") //nolint: errcheck
+			continue
+		}
+		if err == nil && !tu.Completed() && now.After(tu.Expires) {
+			ts.remove(id) //nolint: errcheck
+		}
+	}
+	return nil
+}
+
+func (ts *TusServer) save(tu *TusUpload) error {
+	data, err := json.Marshal(tu)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ts.FilePath(tu.ID)+".info", data, 0600)
+}
+
+func (ts *TusServer) remove(id string) error {
+	err := os.Remove(ts.FilePath(id))
+	if err2 := os.Remove(ts.FilePath(id) + ".info"); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func (ts *TusServer) lock(id string) bool {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.locks[id] {
+		return false
+	}
+	ts.locks[id] = true
+	return true
+}
+
+func (ts *TusServer) unlock(id string) {
+	ts.mutex.Lock()
+	delete(ts.locks, id)
+	ts.mutex.Unlock()
+}
+
+// checkVersion set the Tus-Resumable response header and check the Tus-Resumable request header
+func (ts *TusServer) checkVersion(c *gin.Context) bool {
+	c.Header("Tus-Resumable", TusVersion)
+	if c.GetHeader("Tus-Resumable") != TusVersion {
+		c.Header("Tus-Version", TusVersion)
+		c.AbortWithStatus(http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// get returns the upload of the id parameter, write the error response if failed
+func (ts *TusServer) get(c *gin.Context) *TusUpload {
+	tu, err := ts.Get(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, errTusNotFound) {
+			c.AbortWithStatus(http.StatusNotFound)
+		} else {
+			c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+		}
+		return nil
+	}
+
+	if !tu.Completed() && time.Now().After(tu.Expires) {
+		c.AbortWithStatus(http.StatusGone)
+		return nil
+	}
+	return tu
+}
+
+func (ts *TusServer) options(c *gin.Context) {
+	c.Header("Tus-Resumable", TusVersion)
+	c.Header("Tus-Version", TusVersion)
+	c.Header("Tus-Extension", "creation,termination,expiration")
+	if ts.maxSize > 0 {
+		c.Header("Tus-Max-Size", strconv.FormatInt(ts.maxSize, 10))
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ts *TusServer) create(c *gin.Context, base string) {
+	if !ts.checkVersion(c) {
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	if ts.maxSize > 0 && length > ts.maxSize {
+		c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	meta, err := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	tu := &TusUpload{
+		ID:       randomName(),
+		Length:   length,
+		Metadata: meta,
+		RawMeta:  c.GetHeader("Upload-Metadata"),
+		Created:  now,
+		Expires:  now.Add(ts.expiration),
+	}
+
+	if err := os.MkdirAll(ts.dir, 0750); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+		return
+	}
+	if err := os.WriteFile(ts.FilePath(tu.ID), nil, 0600); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+		return
+	}
+	if err := ts.save(tu); err != nil {
+		ts.remove(tu.ID)                                      //nolint: errcheck
+		c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+		return
+	}
+
+	ts.cleanPeriodically()
+
+	c.Header("Location", base+"/"+tu.ID)
+	c.Header("Upload-Expires", tu.Expires.UTC().Format(http.TimeFormat))
+
+	// the zero length upload is completed by the creation
+	if tu.Completed() && ts.onComplete != nil {
+		ts.onComplete(c, tu)
+		if c.IsAborted() {
+			return
+		}
+	}
+	c.Status(http.StatusCreated)
+}
+
+// cleanPeriodically call Clean() in background if the clean interval is elapsed
+func (ts *TusServer) cleanPeriodically() {
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&ts.lastClean)
+	if now-last >= int64(ts.cleanInterval) && atomic.CompareAndSwapInt64(&ts.lastClean, last, now) {
+		go ts.Clean() //nolint: errcheck
+	}
+}
+
+func (ts *TusServer) head(c *gin.Context) {
+	if !ts.checkVersion(c) {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+
+	tu := ts.get(c)
+	if tu == nil {
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(tu.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(tu.Length, 10))
+	if tu.RawMeta != "" {
+		c.Header("Upload-Metadata", tu.RawMeta)
+	}
+	if !tu.Completed() {
+		c.Header("Upload-Expires", tu.Expires.UTC().Format(http.TimeFormat))
+	}
+	c.Status(http.StatusOK)
+}
+
+func (ts *TusServer) patch(c *gin.Context) {
+	if !ts.checkVersion(c) {
+		return
+	}
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.AbortWithStatus(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	id := c.Param("id")
+	if !ts.lock(id) {
+		c.AbortWithStatus(http.StatusConflict)
+		return
+	}
+	defer ts.unlock(id)
+
+	tu := ts.get(c)
+	if tu == nil {
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	if offset != tu.Offset {
+		c.AbortWithStatus(http.StatusConflict)
+		return
+	}
+
+	remain := tu.Length - tu.Offset
+	if c.Request.ContentLength > remain {
+		c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	f, err := os.OpenFile(ts.FilePath(id), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+		return
+	}
+
+	// the received content is kept even if the connection is broken, so the client can resume the upload
+	fw := &fileWriter{f: f}
+	n, _ := io.Copy(fw, io.LimitReader(c.Request.Body, remain))
+	if err := f.Close(); err != nil && fw.err == nil {
+		fw.err = err
+	}
+	if fw.err != nil {
+		// the client should get the stored offset by HEAD and resume the upload
+		c.AbortWithError(http.StatusInternalServerError, fw.err) //nolint: errcheck
+		return
+	}
+
+	tu.Offset += n
+	if !tu.Completed() {
+		tu.Expires = time.Now().Add(ts.expiration)
+		if err := ts.save(tu); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+			return
+		}
+		c.Header("Upload-Expires", tu.Expires.UTC().Format(http.TimeFormat))
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(tu.Offset, 10))
+	// only the PATCH which completes the upload calls the complete handler
+	if n > 0 && tu.Completed() && ts.onComplete != nil {
+		ts.onComplete(c, tu)
+		if c.IsAborted() {
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ts *TusServer) delete(c *gin.Context) {
+	if !ts.checkVersion(c) {
+		return
+	}
+
+	id := c.Param("id")
+	if !ts.lock(id) {
+		c.AbortWithStatus(http.StatusConflict)
+		return
+	}
+	defer ts.unlock(id)
+
+	if tu := ts.get(c); tu == nil {
+		return
+	}
+
+	if err := ts.remove(id); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// parseTusMetadata parse the Upload-Metadata header value "key1 base64value1,key2 base64value2"
+func parseTusMetadata(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	meta := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+
+		k, v := kv, ""
+		if i := strings.IndexByte(kv, ' '); i >= 0 {
+			k = kv[:i]
+			bs, err := base64.StdEncoding.DecodeString(strings.TrimSpace(kv[i+1:]))
+			if err != nil {
+				return nil, err
+			}
+			v = string(bs)
+		}
+		meta[k] = v
+	}
+	return meta, nil
+}
+
+// isUploadID returns true if the id is a valid upload id (32 lower hex characters)
+func isUploadID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	for _, c := range id {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
+
+// fileWriter a writer which keeps the write error of the file,
+// to distinguish the write error from the read error of io.Copy
+type fileWriter struct {
+	f   *os.File
+	err error
+}
+
+func (fw *fileWriter) Write(p []byte) (int, error) {
+	n, err := fw.f.Write(p)
+	if err != nil {
+		fw.err = err
+	}
+	return n, err
+}
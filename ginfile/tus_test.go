@@ -0,0 +1,252 @@
+package ginfile
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func tusRequest(r http.Handler, method, target string, header map[string]string, body string) *httptest.ResponseRecorder {
+	var br io.Reader
+	if body != "" {
+		br = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, br)
+	req.Header.Set("Tus-Resumable", TusVersion)
+	for k, v := range header {
+		if v == "" {
+			req.Header.Del(k)
+		} else {
+			req.Header.Set(k, v)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestParseTusMetadata(t *testing.T) {
+	meta, err := parseTusMetadata("filename d29ybGRfZG9taW5hdGlvbl9wbGFuLnBkZg==, is_confidential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta["filename"] != "world_domination_plan.pdf" || len(meta) != 2 {
+		t.Errorf("meta = %v", meta)
+	}
+	if _, ok := meta["is_confidential"]; !ok {
+		t.Errorf("meta = %v", meta)
+	}
+
+	if _, err := parseTusMetadata("filename !!!"); err == nil {
+		t.Error("invalid metadata is parsed")
+	}
+}
+
+func TestTusServer(t *testing.T) {
+	dir := t.TempDir()
+
+	completed := ""
+	ts := NewTusServer(dir)
+	ts.SetMaxSize(100)
+	ts.SetCompleteHandler(func(c *gin.Context, tu *TusUpload) {
+		data, _ := os.ReadFile(ts.FilePath(tu.ID))
+		completed = tu.Metadata["filename"] + ":" + string(data)
+	})
+
+	r := gin.New()
+	ts.Mount(r.Group("/files"))
+
+	// options
+	w := tusRequest(r, "OPTIONS", "/files/", map[string]string{"Tus-Resumable": ""}, "")
+	if w.Code != 204 || w.Header().Get("Tus-Version") != TusVersion || w.Header().Get("Tus-Max-Size") != "100" ||
+		w.Header().Get("Tus-Extension") != "creation,termination,expiration" {
+		t.Errorf("OPTIONS = %d %v", w.Code, w.Header())
+	}
+
+	// create
+	if w := tusRequest(r, "POST", "/files/", map[string]string{"Tus-Resumable": "", "Upload-Length": "10"}, ""); w.Code != 412 {
+		t.Errorf("POST without Tus-Resumable = %d", w.Code)
+	}
+	if w := tusRequest(r, "POST", "/files/", map[string]string{"Upload-Length": "101"}, ""); w.Code != 413 {
+		t.Errorf("POST too large = %d", w.Code)
+	}
+	if w := tusRequest(r, "POST", "/files/", nil, ""); w.Code != 400 {
+		t.Errorf("POST without Upload-Length = %d", w.Code)
+	}
+
+	w = tusRequest(r, "POST", "/files/", map[string]string{"Upload-Length": "10", "Upload-Metadata": "filename YS50eHQ="}, "")
+	loc := w.Header().Get("Location")
+	if w.Code != 201 || !strings.HasPrefix(loc, "/files/") || w.Header().Get("Upload-Expires") == "" || w.Header().Get("Tus-Resumable") != TusVersion {
+		t.Fatalf("POST = %d %v", w.Code, w.Header())
+	}
+
+	// head
+	w = tusRequest(r, "HEAD", loc, nil, "")
+	if w.Code != 200 || w.Header().Get("Upload-Offset") != "0" || w.Header().Get("Upload-Length") != "10" ||
+		w.Header().Get("Upload-Metadata") != "filename YS50eHQ=" || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("HEAD = %d %v", w.Code, w.Header())
+	}
+
+	// patch
+	const oct = "application/offset+octet-stream"
+	if w := tusRequest(r, "PATCH", loc, map[string]string{"Upload-Offset": "0", "Content-Type": "text/plain"}, "01234"); w.Code != 415 {
+		t.Errorf("PATCH wrong content type = %d", w.Code)
+	}
+	if w := tusRequest(r, "PATCH", loc, map[string]string{"Upload-Offset": "3", "Content-Type": oct}, "01234"); w.Code != 409 {
+		t.Errorf("PATCH wrong offset = %d", w.Code)
+	}
+	if w := tusRequest(r, "PATCH", loc, map[string]string{"Upload-Offset": "0", "Content-Type": oct}, "0123456789A"); w.Code != 413 {
+		t.Errorf("PATCH too large = %d", w.Code)
+	}
+
+	w = tusRequest(r, "PATCH", loc, map[string]string{"Upload-Offset": "0", "Content-Type": oct}, "01234")
+	if w.Code != 204 || w.Header().Get("Upload-Offset") != "5" || completed != "" {
+		t.Errorf("PATCH 1 = %d %v", w.Code, w.Header())
+	}
+
+	w = tusRequest(r, "HEAD", loc, nil, "")
+	if w.Header().Get("Upload-Offset") != "5" {
+		t.Errorf("HEAD Upload-Offset = %q", w.Header().Get("Upload-Offset"))
+	}
+
+	w = tusRequest(r, "PATCH", loc, map[string]string{"Upload-Offset": "5", "Content-Type": oct}, "56789")
+	if w.Code != 204 || w.Header().Get("Upload-Offset") != "10" {
+		t.Errorf("PATCH 2 = %d %v", w.Code, w.Header())
+	}
+	if completed != "a.txt:0123456789" {
+		t.Errorf("completed = %q", completed)
+	}
+
+	// the empty PATCH to the completed upload does not call the complete handler again
+	completed = ""
+	for i := 0; i < 2; i++ {
+		w = tusRequest(r, "PATCH", loc, map[string]string{"Upload-Offset": "10", "Content-Type": oct}, "")
+		if w.Code != 204 || w.Header().Get("Upload-Offset") != "10" || completed != "" {
+			t.Errorf("PATCH completed = %d %v %q", w.Code, w.Header(), completed)
+		}
+	}
+
+	// delete
+	if w := tusRequest(r, "DELETE", loc, nil, ""); w.Code != 204 {
+		t.Errorf("DELETE = %d", w.Code)
+	}
+	if w := tusRequest(r, "HEAD", loc, nil, ""); w.Code != 404 {
+		t.Errorf("HEAD deleted = %d", w.Code)
+	}
+	if w := tusRequest(r, "HEAD", "/files/..%2f..%2fetc%2fpasswd", nil, ""); w.Code != 404 {
+		t.Errorf("HEAD invalid id = %d", w.Code)
+	}
+}
+
+func TestTusServerExpiration(t *testing.T) {
+	dir := t.TempDir()
+
+	ts := NewTusServer(dir)
+	ts.SetExpiration(time.Millisecond * 10)
+
+	r := gin.New()
+	ts.Mount(r.Group("/files"))
+
+	loc1 := tusRequest(r, "POST", "/files/", map[string]string{"Upload-Length": "5"}, "").Header().Get("Location")
+	loc2 := tusRequest(r, "POST", "/files/", map[string]string{"Upload-Length": "0"}, "").Header().Get("Location")
+
+	time.Sleep(time.Millisecond * 20)
+
+	if w := tusRequest(r, "HEAD", loc1, nil, ""); w.Code != 410 {
+		t.Errorf("HEAD expired = %d", w.Code)
+	}
+	if w := tusRequest(r, "HEAD", loc2, nil, ""); w.Code != 200 {
+		t.Errorf("HEAD completed = %d", w.Code)
+	}
+
+	if err := ts.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(listDir(t, dir)); n != 2 {
+		t.Errorf("files after Clean() = %v, want the completed upload only", listDir(t, dir))
+	}
+	if w := tusRequest(r, "HEAD", loc1, nil, ""); w.Code != 404 {
+		t.Errorf("HEAD cleaned = %d", w.Code)
+	}
+}
+
+func TestTusServerZeroLength(t *testing.T) {
+	ts := NewTusServer(t.TempDir())
+
+	completed := 0
+	ts.SetCompleteHandler(func(c *gin.Context, tu *TusUpload) {
+		completed++
+	})
+
+	r := gin.New()
+	ts.Mount(r.Group("/files"))
+
+	w := tusRequest(r, "POST", "/files/", map[string]string{"Upload-Length": "0"}, "")
+	if w.Code != 201 || completed != 1 {
+		t.Fatalf("POST = %d, completed = %d", w.Code, completed)
+	}
+
+	loc := w.Header().Get("Location")
+	w = tusRequest(r, "PATCH", loc, map[string]string{"Upload-Offset": "0", "Content-Type": "application/offset+octet-stream"}, "")
+	if w.Code != 204 || completed != 1 {
+		t.Errorf("PATCH = %d, completed = %d", w.Code, completed)
+	}
+}
+
+func TestTusServerWriteError(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+
+	dir := t.TempDir()
+	ts := NewTusServer(dir)
+
+	r := gin.New()
+	ts.Mount(r.Group("/files"))
+
+	loc := tusRequest(r, "POST", "/files/", map[string]string{"Upload-Length": "5"}, "").Header().Get("Location")
+	id := path.Base(loc)
+
+	// the content file is replaced by the full device
+	os.Remove(ts.FilePath(id))
+	if err := os.Symlink("/dev/full", ts.FilePath(id)); err != nil {
+		t.Skip(err)
+	}
+
+	w := tusRequest(r, "PATCH", loc, map[string]string{"Upload-Offset": "0", "Content-Type": "application/offset+octet-stream"}, "01234")
+	if w.Code != 500 {
+		t.Errorf("PATCH write error = %d", w.Code)
+	}
+}
+
+func TestTusServerCleanPeriodically(t *testing.T) {
+	dir := t.TempDir()
+
+	ts := NewTusServer(dir)
+	ts.SetExpiration(time.Millisecond * 10)
+	ts.cleanInterval = 0
+
+	r := gin.New()
+	ts.Mount(r.Group("/files"))
+
+	loc := tusRequest(r, "POST", "/files/", map[string]string{"Upload-Length": "5"}, "").Header().Get("Location")
+	time.Sleep(time.Millisecond * 20)
+
+	// the creation cleans the expired uploads in background
+	tusRequest(r, "POST", "/files/", map[string]string{"Upload-Length": "5"}, "")
+	for i := 0; i < 100; i++ {
+		if _, err := ts.Get(path.Base(loc)); err != nil {
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	t.Errorf("the expired upload %s is not cleaned", loc)
+}
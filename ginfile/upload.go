@@ -0,0 +1,395 @@
+package ginfile
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Storage the upload file storage
+type Storage interface {
+	// Save save the content of the reader r as the file name, returns the size of the saved content.
+	// The partial saved file should be removed if an error occurs.
+	Save(name string, r io.Reader) (int64, error)
+
+	// Remove remove the saved file name.
+	// It is used to remove the already saved files when a later file of the same request fails.
+	Remove(name string) error
+}
+
+// DirStorage a Storage which saves the files to the local directory.
+// The file is written to a temporary file and renamed to the file name when the content is completely written,
+// so the incomplete file is never visible.
+type DirStorage string
+
+// Save implements Storage
+func (ds DirStorage) Save(name string, r io.Reader) (int64, error) {
+	dir := string(ds)
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return 0, err
+	}
+
+	tf, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tf.Name()) //nolint: errcheck
+
+	n, err := io.Copy(tf, r)
+	if err == nil {
+		err = tf.Sync()
+	}
+	if cerr := tf.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, err
+	}
+
+	return n, os.Rename(tf.Name(), filepath.Join(dir, filepath.Base(name)))
+}
+
+// Remove implements Storage
+func (ds DirStorage) Remove(name string) error {
+	dir := string(ds)
+	if dir == "" {
+		dir = "."
+	}
+	return os.Remove(filepath.Join(dir, filepath.Base(name)))
+}
+
+// UploadedFile the uploaded file information of the JSON response
+type UploadedFile struct {
+	Name     string `json:"name"`     // the stored file name
+	Filename string `json:"filename"` // the original file name
+	Size     int64  `json:"size"`     // the file size
+	Type     string `json:"type"`     // the sniffed content type
+}
+
+var (
+	errUploadTooLarge = errors.New("ginfile: upload file too large")
+	errUploadExt      = errors.New("ginfile: upload file extension not allowed")
+	errUploadType     = errors.New("ginfile: upload file type not allowed")
+	errUploadNoFile   = errors.New("ginfile: no upload file")
+	errUploadTooMany  = errors.New("ginfile: too many upload files")
+)
+
+// uploadReadError the error occurred when reading the request body
+type uploadReadError struct {
+	err error
+}
+
+func (e *uploadReadError) Error() string {
+	return "ginfile: read upload: " + e.err.Error()
+}
+
+func (e *uploadReadError) Unwrap() error {
+	return e.err
+}
+
+// Uploader a file upload handler which accepts the multipart/form-data POST upload and the raw PUT upload.
+//
+//	uploader := ginfile.NewUploader(ginfile.DirStorage("./uploads"))
+//	uploader.SetMaxSize(10 << 20)
+//	uploader.SetAllowedExts(".jpg", ".png")
+//	uploader.SetAllowedTypes("image/*")
+//	router.POST("/upload", uploader.Handler())
+//	router.PUT("/upload/:name", uploader.Handler())
+//
+// The multipart/form-data POST request uploads the files of the form field (default: "file"),
+// a JSON array of the UploadedFile is returned with the status 201 Created.
+// The raw PUT request uploads the request body, the original file name is the last element of the URL path,
+// a JSON UploadedFile is returned with the status 201 Created.
+// The error is returned as a JSON {"error": "..."} with the status code
+// 400 (no file or malformed request), 413 (too large or too many files), 415 (extension or type not allowed)
+// or 500 (storage error).
+// If a file of the multipart request fails, the files already saved by the same request are removed from the storage.
+type Uploader struct {
+	storage  Storage
+	maxSize  int64
+	maxTotal int64
+	maxFiles int
+	field    string
+	exts     map[string]bool
+	types    []string
+}
+
+// NewUploader create a file upload handler which saves the files to the storage
+func NewUploader(storage Storage) *Uploader {
+	return &Uploader{storage: storage, field: "file"}
+}
+
+// SetMaxSize set the max size of a upload file.
+// Default: 0 (unlimited)
+func (u *Uploader) SetMaxSize(maxSize int64) {
+	u.maxSize = maxSize
+}
+
+// SetMaxTotalSize set the max size of the whole request body.
+// Default: 0 (unlimited)
+func (u *Uploader) SetMaxTotalSize(maxTotal int64) {
+	u.maxTotal = maxTotal
+}
+
+// SetMaxFiles set the max count of the files of a multipart request.
+// Default: 0 (unlimited)
+func (u *Uploader) SetMaxFiles(maxFiles int) {
+	u.maxFiles = maxFiles
+}
+
+// SetFieldName set the multipart form field name of the upload files.
+// Default: "file"
+func (u *Uploader) SetFieldName(name string) {
+	u.field = name
+}
+
+// SetAllowedExts set the allowed file extensions (e.g. ".jpg", ".png"), the extensions are case insensitive.
+// Default: empty (all extensions are allowed)
+func (u *Uploader) SetAllowedExts(exts ...string) {
+	if len(exts) == 0 {
+		u.exts = nil
+		return
+	}
+
+	m := make(map[string]bool, len(exts))
+	for _, e := range exts {
+		m[strings.ToLower(e)] = true
+	}
+	u.exts = m
+}
+
+// SetAllowedTypes set the allowed content types (e.g. "image/png", "image/*") of the upload files.
+// The content type is sniffed from the file content (see http.DetectContentType).
+// Default: empty (all types are allowed)
+func (u *Uploader) SetAllowedTypes(types ...string) {
+	u.types = types
+}
+
+// Handler returns the gin.HandlerFunc
+func (u *Uploader) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u.handle(c)
+	}
+}
+
+// handle process gin request
+func (u *Uploader) handle(c *gin.Context) {
+	if u.maxTotal > 0 {
+		c.Request.Body = &limitReadCloser{limitReader{r: c.Request.Body, n: u.maxTotal}, c.Request.Body}
+	}
+
+	if c.Request.Method == http.MethodPut {
+		uf, err := u.save(path.Base(c.Request.URL.Path), c.Request.Body)
+		if err != nil {
+			uploadError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, uf)
+		return
+	}
+
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		uploadError(c, err)
+		return
+	}
+
+	ufs := []*UploadedFile{}
+	fail := func(err error) {
+		for _, uf := range ufs {
+			u.storage.Remove(uf.Name) //nolint: errcheck
+		}
+		if lrc, ok := c.Request.Body.(*limitReadCloser); ok && lrc.over {
+			err = errUploadTooLarge
+		}
+		uploadError(c, err)
+	}
+
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fail(&uploadReadError{err})
+			return
+		}
+
+		if p.FormName() != u.field || p.FileName() == "" {
+			p.Close()
+			continue
+		}
+
+		if u.maxFiles > 0 && len(ufs) >= u.maxFiles {
+			p.Close()
+			fail(errUploadTooMany)
+			return
+		}
+
+		uf, err := u.save(p.FileName(), p)
+		p.Close()
+		if err != nil {
+			fail(err)
+			return
+		}
+		ufs = append(ufs, uf)
+	}
+
+	if len(ufs) == 0 {
+		uploadError(c, errUploadNoFile)
+		return
+	}
+	c.JSON(http.StatusCreated, ufs)
+}
+
+// save check and save the upload file
+func (u *Uploader) save(filename string, r io.Reader) (*UploadedFile, error) {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	ext := strings.ToLower(path.Ext(filename))
+	if u.exts != nil && !u.exts[ext] {
+		return nil, errUploadExt
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		if errors.Is(err, errUploadTooLarge) {
+			return nil, err
+		}
+		return nil, &uploadReadError{err}
+	}
+	head = head[:n]
+
+	ct := http.DetectContentType(head)
+	if !u.allowType(ct) {
+		return nil, errUploadType
+	}
+
+	uf := &UploadedFile{
+		Name:     randomName() + ext,
+		Filename: filename,
+		Type:     ct,
+	}
+
+	er := &errorReader{r: r}
+	r = io.MultiReader(bytes.NewReader(head), er)
+	if u.maxSize > 0 {
+		r = &limitReader{r: r, n: u.maxSize}
+	}
+
+	uf.Size, err = u.storage.Save(uf.Name, r)
+	if err != nil {
+		if er.err != nil {
+			// the request read error takes precedence over the storage error caused by it
+			return nil, er.err
+		}
+		return nil, err
+	}
+	return uf, nil
+}
+
+func (u *Uploader) allowType(ct string) bool {
+	if len(u.types) == 0 {
+		return true
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	for _, t := range u.types {
+		if t == mt || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mt, t[:len(t)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// randomName returns a random hex file name
+func randomName() string {
+	b := make([]byte, 16)
+	rand.Read(b) //nolint: errcheck
+	return hex.EncodeToString(b)
+}
+
+// uploadError write the JSON error response of the upload error
+func uploadError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errUploadTooLarge), errors.Is(err, errUploadTooMany):
+		code = http.StatusRequestEntityTooLarge
+	case errors.Is(err, errUploadExt), errors.Is(err, errUploadType):
+		code = http.StatusUnsupportedMediaType
+	case errors.Is(err, errUploadNoFile), errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary):
+		code = http.StatusBadRequest
+	case errors.Is(err, multipart.ErrMessageTooLarge):
+		code = http.StatusRequestEntityTooLarge
+	default:
+		var re *uploadReadError
+		if errors.As(err, &re) {
+			code = http.StatusBadRequest
+		}
+	}
+	c.AbortWithStatusJSON(code, gin.H{"error": err.Error()})
+}
+
+// limitReader a reader which returns errUploadTooLarge if the content exceeds n bytes
+type limitReader struct {
+	r    io.Reader
+	n    int64
+	over bool
+}
+
+func (lr *limitReader) Read(p []byte) (int, error) {
+	if lr.over {
+		return 0, errUploadTooLarge
+	}
+	if int64(len(p)) > lr.n+1 {
+		p = p[:lr.n+1]
+	}
+	n, err := lr.r.Read(p)
+	if int64(n) > lr.n {
+		lr.over = true
+		return int(lr.n), errUploadTooLarge
+	}
+	lr.n -= int64(n)
+	return n, err
+}
+
+// limitReadCloser a limitReader of the request body
+type limitReadCloser struct {
+	limitReader
+	io.Closer
+}
+
+// errorReader a reader which records the read error (except io.EOF) of the request body
+type errorReader struct {
+	r   io.Reader
+	err error
+}
+
+func (er *errorReader) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) && er.err == nil {
+		if errors.Is(err, errUploadTooLarge) {
+			er.err = err
+		} else {
+			er.err = &uploadReadError{err}
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,226 @@
+package ginfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pngData = append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 100)...)
+
+func newMultipartRequest(t *testing.T, field string, files map[string][]byte) *http.Request {
+	bb := &bytes.Buffer{}
+	mw := multipart.NewWriter(bb)
+	mw.WriteField("memo", "memo") //nolint: errcheck
+	for name, data := range files {
+		fw, err := mw.CreateFormFile(field, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data) //nolint: errcheck
+	}
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", bb)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func listDir(t *testing.T, dir string) []string {
+	des, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, de := range des {
+		names = append(names, de.Name())
+	}
+	return names
+}
+
+func TestUploader(t *testing.T) {
+	dir := t.TempDir()
+
+	u := NewUploader(DirStorage(dir))
+	u.SetMaxSize(200)
+	u.SetAllowedExts(".png", ".txt")
+	u.SetAllowedTypes("image/*", "text/plain")
+
+	r := gin.New()
+	r.POST("/upload", u.Handler())
+	r.PUT("/upload/:name", u.Handler())
+
+	// multipart
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newMultipartRequest(t, "file", map[string][]byte{
+		"a.PNG":          pngData,
+		"../../evil.txt": []byte("hello"),
+	}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST code = %d: %s", w.Code, w.Body.String())
+	}
+
+	ufs := []*UploadedFile{}
+	if err := json.Unmarshal(w.Body.Bytes(), &ufs); err != nil {
+		t.Fatal(err)
+	}
+	if len(ufs) != 2 {
+		t.Fatalf("uploaded files = %d", len(ufs))
+	}
+	for _, uf := range ufs {
+		data, err := os.ReadFile(filepath.Join(dir, uf.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch uf.Filename {
+		case "a.PNG":
+			if uf.Type != "image/png" || uf.Size != int64(len(pngData)) || !strings.HasSuffix(uf.Name, ".png") || !bytes.Equal(data, pngData) {
+				t.Errorf("a.PNG = %+v", uf)
+			}
+		case "evil.txt":
+			if uf.Type != "text/plain; charset=utf-8" || uf.Size != 5 || string(data) != "hello" {
+				t.Errorf("evil.txt = %+v", uf)
+			}
+		default:
+			t.Errorf("unexpected file %+v", uf)
+		}
+	}
+
+	// put
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/upload/b.png", bytes.NewReader(pngData)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("PUT code = %d: %s", w.Code, w.Body.String())
+	}
+	uf := &UploadedFile{}
+	if err := json.Unmarshal(w.Body.Bytes(), uf); err != nil {
+		t.Fatal(err)
+	}
+	if uf.Filename != "b.png" || uf.Size != int64(len(pngData)) {
+		t.Errorf("PUT = %+v", uf)
+	}
+	if n := len(listDir(t, dir)); n != 3 {
+		t.Errorf("files = %d, want 3", n)
+	}
+
+	// errors
+	cs := []struct {
+		req  *http.Request
+		code int
+	}{
+		{httptest.NewRequest("PUT", "/upload/big.png", bytes.NewReader(append(pngData, make([]byte, 200)...))), 413},
+		{httptest.NewRequest("PUT", "/upload/c.exe", bytes.NewReader(pngData)), 415},
+		{httptest.NewRequest("PUT", "/upload/c.png", strings.NewReader("<html><body></body></html>")), 415},
+		{newMultipartRequest(t, "other", map[string][]byte{"a.png": pngData}), 400},
+		{httptest.NewRequest("POST", "/upload", strings.NewReader("x")), 400},
+		{newMultipartRequest(t, "file", map[string][]byte{"big.txt": bytes.Repeat([]byte("x"), 201)}), 413},
+	}
+	for i, c := range cs {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, c.req)
+		if w.Code != c.code {
+			t.Errorf("[%d] code = %d, want %d: %s", i, w.Code, c.code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), `"error"`) {
+			t.Errorf("[%d] body = %s", i, w.Body.String())
+		}
+	}
+
+	if n := len(listDir(t, dir)); n != 3 {
+		t.Errorf("files = %v, want 3 (no partial files)", listDir(t, dir))
+	}
+}
+
+func TestUploaderFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	u := NewUploader(DirStorage(dir))
+	u.SetAllowedExts(".png")
+
+	r := gin.New()
+	r.POST("/upload", u.Handler())
+
+	newRequest := func(names ...string) (*http.Request, *bytes.Buffer) {
+		bb := &bytes.Buffer{}
+		mw := multipart.NewWriter(bb)
+		for _, name := range names {
+			fw, err := mw.CreateFormFile("file", name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fw.Write(pngData) //nolint: errcheck
+		}
+		mw.Close()
+
+		req := httptest.NewRequest("POST", "/upload", bb)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return req, bb
+	}
+
+	// the saved files are removed when a later file fails
+	req, _ := newRequest("a.png", "b.png", "c.exe")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("code = %d: %s", w.Code, w.Body.String())
+	}
+	if names := listDir(t, dir); len(names) != 0 {
+		t.Errorf("files = %v, want none", names)
+	}
+
+	// malformed multipart body
+	req, bb := newRequest("a.png", "b.png")
+	bb.Truncate(bb.Len() - 10)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("malformed code = %d: %s", w.Code, w.Body.String())
+	}
+	if names := listDir(t, dir); len(names) != 0 {
+		t.Errorf("malformed files = %v, want none", names)
+	}
+
+	// too many files
+	u.SetMaxFiles(2)
+	req, _ = newRequest("a.png", "b.png", "c.png")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("max files code = %d: %s", w.Code, w.Body.String())
+	}
+	if names := listDir(t, dir); len(names) != 0 {
+		t.Errorf("max files files = %v, want none", names)
+	}
+
+	// total size
+	u.SetMaxFiles(0)
+	u.SetMaxTotalSize(int64(len(pngData)) * 2)
+	req, _ = newRequest("a.png", "b.png")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("max total code = %d: %s", w.Code, w.Body.String())
+	}
+	if names := listDir(t, dir); len(names) != 0 {
+		t.Errorf("max total files = %v, want none", names)
+	}
+
+	u.SetMaxTotalSize(1 << 20)
+	req, _ = newRequest("a.png", "b.png")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("code = %d: %s", w.Code, w.Body.String())
+	}
+	if names := listDir(t, dir); len(names) != 2 {
+		t.Errorf("files = %v, want 2", names)
+	}
+}
@@ -0,0 +1,374 @@
+package ginfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ZipFS a http.FileSystem which serves the entries of a zip archive directly, without extracting.
+// The modification times of the entries are the stored modification times of the zip archive.
+// The static file handlers serve the deflated entries as the "Content-Encoding: gzip" responses
+// without decompression and recompression, if the request "Accept-Encoding" header accepts gzip.
+// The stored (not compressed) entries support the efficient Range request.
+// The directories which are not stored in the archive are implied by the entry names.
+//
+//	zfs, err := ginfile.OpenZipFS("./static.zip")
+//	ginfile.StaticFS(g, "/", "", zfs, ginfile.Public1Year)
+type ZipFS struct {
+	ra     io.ReaderAt
+	closer io.Closer
+	files  map[string]*zip.File // the regular files
+	dirs   map[string]*zipDir   // the directories
+}
+
+// zipDir a directory of the zip archive
+type zipDir struct {
+	info    fs.FileInfo
+	entries []fs.FileInfo
+}
+
+// zipDirInfo the fs.FileInfo of the implied directory
+type zipDirInfo string
+
+func (zdi zipDirInfo) Name() string       { return string(zdi) }
+func (zdi zipDirInfo) Size() int64        { return 0 }
+func (zdi zipDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (zdi zipDirInfo) ModTime() time.Time { return time.Time{} }
+func (zdi zipDirInfo) IsDir() bool        { return true }
+func (zdi zipDirInfo) Sys() interface{}   { return nil }
+
+// OpenZipFS open the zip archive file as a ZipFS.
+// The ZipFS should be closed by Close() if it is no longer used.
+func OpenZipFS(name string) (*ZipFS, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	zfs, err := NewZipFS(f, fi.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	zfs.closer = f
+	return zfs, nil
+}
+
+// NewZipFS create a ZipFS of the zip archive data ra with the size.
+//
+//	zfs, err := ginfile.NewZipFS(bytes.NewReader(data), int64(len(data)))
+func NewZipFS(ra io.ReaderAt, size int64) (*ZipFS, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+
+	zfs := &ZipFS{
+		ra:    ra,
+		files: make(map[string]*zip.File),
+		dirs:  map[string]*zipDir{"/": {info: zipDirInfo("/")}},
+	}
+
+	for _, zf := range zr.File {
+		name := path.Clean("/" + zf.Name)
+		if name == "/" {
+			continue
+		}
+
+		if strings.HasSuffix(zf.Name, "/") || zf.Mode().IsDir() {
+			zd := zfs.mkdir(name)
+			zd.info = zf.FileInfo()
+			continue
+		}
+		if !zf.Mode().IsRegular() {
+			// symbolic links and other special files are not served
+			continue
+		}
+		if _, ok := zfs.files[name]; ok {
+			// the duplicated entry, the first one wins
+			continue
+		}
+
+		zfs.files[name] = zf
+		zd := zfs.mkdir(path.Dir(name))
+		zd.entries = append(zd.entries, zf.FileInfo())
+	}
+
+	for _, zd := range zfs.dirs {
+		sort.Slice(zd.entries, func(i, j int) bool { return zd.entries[i].Name() < zd.entries[j].Name() })
+	}
+	return zfs, nil
+}
+
+// mkdir returns the directory of the name, the parent directories are created if not exist
+func (zfs *ZipFS) mkdir(name string) *zipDir {
+	if zd, ok := zfs.dirs[name]; ok {
+		return zd
+	}
+
+	zd := &zipDir{info: zipDirInfo(path.Base(name))}
+	zfs.dirs[name] = zd
+
+	pd := zfs.mkdir(path.Dir(name))
+	pd.entries = append(pd.entries, &zipDirEntry{zd})
+	return zd
+}
+
+// zipDirEntry the fs.FileInfo of the sub directory in the directory entries,
+// the info is resolved lazily because the stored directory entry may come after the files.
+type zipDirEntry struct {
+	zd *zipDir
+}
+
+func (zde *zipDirEntry) Name() string       { return zde.zd.info.Name() }
+func (zde *zipDirEntry) Size() int64        { return 0 }
+func (zde *zipDirEntry) Mode() fs.FileMode  { return zde.zd.info.Mode() }
+func (zde *zipDirEntry) ModTime() time.Time { return zde.zd.info.ModTime() }
+func (zde *zipDirEntry) IsDir() bool        { return true }
+func (zde *zipDirEntry) Sys() interface{}   { return zde.zd.info.Sys() }
+
+// Close close the underlying zip archive file opened by OpenZipFS
+func (zfs *ZipFS) Close() error {
+	if zfs.closer != nil {
+		return zfs.closer.Close()
+	}
+	return nil
+}
+
+// Open implements http.FileSystem
+func (zfs *ZipFS) Open(name string) (http.File, error) {
+	name = path.Clean("/" + name)
+
+	if zf, ok := zfs.files[name]; ok {
+		return newZipFile(zfs, zf)
+	}
+	if zd, ok := zfs.dirs[name]; ok {
+		return &zipDirFile{name: name, zd: zd}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// zipFile a http.File of the zip archive entry.
+// The stored entry is read by a io.SectionReader of the archive,
+// the deflated entry is decompressed on reading, the backward seek reopens the entry.
+type zipFile struct {
+	zfs  *ZipFS
+	zf   *zip.File
+	sr   *io.SectionReader // the stored entry content
+	rc   io.ReadCloser     // the decompressor of the deflated entry
+	pos  int64             // the seek position
+	rpos int64             // the read position of the decompressor
+}
+
+func newZipFile(zfs *ZipFS, zf *zip.File) (*zipFile, error) {
+	zfl := &zipFile{zfs: zfs, zf: zf}
+
+	if zf.Method == zip.Store {
+		off, err := zf.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+		zfl.sr = io.NewSectionReader(zfs.ra, off, int64(zf.UncompressedSize64))
+	}
+	return zfl, nil
+}
+
+// Read implements http.File
+func (zfl *zipFile) Read(p []byte) (int, error) {
+	if zfl.sr != nil {
+		return zfl.sr.Read(p)
+	}
+
+	if zfl.rc == nil || zfl.rpos > zfl.pos {
+		if zfl.rc != nil {
+			zfl.rc.Close()
+		}
+		rc, err := zfl.zf.Open()
+		if err != nil {
+			return 0, err
+		}
+		zfl.rc, zfl.rpos = rc, 0
+	}
+	if zfl.rpos < zfl.pos {
+		n, err := io.CopyN(io.Discard, zfl.rc, zfl.pos-zfl.rpos)
+		zfl.rpos += n
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := zfl.rc.Read(p)
+	zfl.pos += int64(n)
+	zfl.rpos += int64(n)
+	return n, err
+}
+
+// Seek implements http.File
+func (zfl *zipFile) Seek(offset int64, whence int) (int64, error) {
+	if zfl.sr != nil {
+		return zfl.sr.Seek(offset, whence)
+	}
+
+	switch whence {
+	case io.SeekCurrent:
+		offset += zfl.pos
+	case io.SeekEnd:
+		offset += int64(zfl.zf.UncompressedSize64)
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: zfl.zf.Name, Err: fs.ErrInvalid}
+	}
+	zfl.pos = offset
+	return offset, nil
+}
+
+// Close implements http.File
+func (zfl *zipFile) Close() error {
+	if zfl.rc != nil {
+		return zfl.rc.Close()
+	}
+	return nil
+}
+
+// Readdir implements http.File
+func (zfl *zipFile) Readdir(count int) ([]fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "readdir", Path: zfl.zf.Name, Err: errors.New("not a directory")}
+}
+
+// Stat implements http.File
+func (zfl *zipFile) Stat() (fs.FileInfo, error) {
+	return zfl.zf.FileInfo(), nil
+}
+
+// gzipReader returns the gzip stream of the raw deflated data,
+// returns nil if the entry is not deflated.
+func (zfl *zipFile) gzipReader() io.ReadSeeker {
+	if zfl.zf.Method != zip.Deflate {
+		return nil
+	}
+
+	off, err := zfl.zf.DataOffset()
+	if err != nil {
+		return nil
+	}
+
+	// the gzip header: magic, CM (deflate), FLG, MTIME, XFL, OS (unknown)
+	header := []byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 255}
+
+	// the gzip trailer: CRC-32, ISIZE
+	trailer := make([]byte, 8)
+	binary.LittleEndian.PutUint32(trailer, zfl.zf.CRC32)
+	binary.LittleEndian.PutUint32(trailer[4:], uint32(zfl.zf.UncompressedSize64))
+
+	mra := multiReaderAt{
+		io.NewSectionReader(bytes.NewReader(header), 0, int64(len(header))),
+		io.NewSectionReader(zfl.zfs.ra, off, int64(zfl.zf.CompressedSize64)),
+		io.NewSectionReader(bytes.NewReader(trailer), 0, int64(len(trailer))),
+	}
+	return io.NewSectionReader(mra, 0, mra.size())
+}
+
+// zippedFile returns the zip entry file of f, returns nil if f is not a ZipFS file
+func zippedFile(f http.File) *zipFile {
+	if pf, ok := f.(*policyFile); ok {
+		f = pf.File
+	}
+	if zfl, ok := f.(*zipFile); ok {
+		return zfl
+	}
+	return nil
+}
+
+// multiReaderAt the concatenated io.ReaderAt of the sections
+type multiReaderAt []*io.SectionReader
+
+func (mra multiReaderAt) size() int64 {
+	var n int64
+	for _, sr := range mra {
+		n += sr.Size()
+	}
+	return n
+}
+
+// ReadAt implements io.ReaderAt
+func (mra multiReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for _, sr := range mra {
+		if off >= sr.Size() {
+			off -= sr.Size()
+			continue
+		}
+
+		m, err := sr.ReadAt(p[n:], off)
+		n += m
+		if n == len(p) {
+			return n, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		off = 0
+	}
+	return n, io.EOF
+}
+
+// zipDirFile a http.File of the zip archive directory
+type zipDirFile struct {
+	name   string
+	zd     *zipDir
+	offset int // the Readdir offset
+}
+
+// Read implements http.File
+func (zdf *zipDirFile) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: zdf.name, Err: errors.New("is a directory")}
+}
+
+// Seek implements http.File
+func (zdf *zipDirFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, &fs.PathError{Op: "seek", Path: zdf.name, Err: errors.New("is a directory")}
+}
+
+// Close implements http.File
+func (zdf *zipDirFile) Close() error {
+	return nil
+}
+
+// Readdir implements http.File
+func (zdf *zipDirFile) Readdir(count int) ([]fs.FileInfo, error) {
+	ents := zdf.zd.entries[zdf.offset:]
+	if count > 0 {
+		if len(ents) == 0 {
+			return nil, io.EOF
+		}
+		if count < len(ents) {
+			ents = ents[:count]
+		}
+	}
+	zdf.offset += len(ents)
+
+	fis := make([]fs.FileInfo, len(ents))
+	copy(fis, ents)
+	return fis, nil
+}
+
+// Stat implements http.File
+func (zdf *zipDirFile) Stat() (fs.FileInfo, error) {
+	return zdf.zd.info, nil
+}
@@ -0,0 +1,203 @@
+package ginfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var zipModTime = time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC)
+
+func zipBytes(t *testing.T) []byte {
+	bb := &bytes.Buffer{}
+	zw := zip.NewWriter(bb)
+
+	entries := []struct {
+		name   string
+		method uint16
+		data   string
+	}{
+		{"index.html", zip.Deflate, "<html>" + strings.Repeat("index ", 100) + "</html>"},
+		{"css/", zip.Store, ""},
+		{"css/app.css", zip.Deflate, "body { color: red; }"},
+		{"img/logo.png", zip.Store, "0123456789"},
+		{"js/app.js", zip.Deflate, strings.Repeat("console.log(1);\n", 100)},
+	}
+	for _, e := range entries {
+		fh := &zip.FileHeader{Name: e.name, Method: e.method, Modified: zipModTime}
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, e.data) //nolint: errcheck
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bb.Bytes()
+}
+
+func newTestZipFS(t *testing.T) *ZipFS {
+	data := zipBytes(t)
+	zfs, err := NewZipFS(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zfs
+}
+
+func TestZipFSOpen(t *testing.T) {
+	zfs := newTestZipFS(t)
+
+	f, err := zfs.Open("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fis, _ := f.Readdir(-1)
+	names := []string{}
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	if a, want := strings.Join(names, ","), "css,img,index.html,js"; a != want {
+		t.Errorf("Readdir = %q, want %q", a, want)
+	}
+
+	f, err = zfs.Open("/js/app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, _ := f.Stat()
+	if !fi.ModTime().Equal(zipModTime) {
+		t.Errorf("ModTime = %v, want %v", fi.ModTime(), zipModTime)
+	}
+
+	// seek backward and forward on the deflated entry
+	buf := make([]byte, 8)
+	f.Seek(16, io.SeekStart) //nolint: errcheck
+	io.ReadFull(f, buf)      //nolint: errcheck
+	if a := string(buf); a != "console." {
+		t.Errorf("Read(16) = %q", a)
+	}
+	f.Seek(1, io.SeekStart) //nolint: errcheck
+	io.ReadFull(f, buf)     //nolint: errcheck
+	if a := string(buf); a != "onsole.l" {
+		t.Errorf("Read(1) = %q", a)
+	}
+	if n, _ := f.Seek(0, io.SeekEnd); n != 1600 {
+		t.Errorf("Seek(end) = %d", n)
+	}
+
+	for _, name := range []string{"/none", "/css/none.css", "/../index.html/x"} {
+		if _, err := zfs.Open(name); !os.IsNotExist(err) {
+			t.Errorf("Open(%q) = %v, want not exist", name, err)
+		}
+	}
+}
+
+func TestZipFSStatic(t *testing.T) {
+	zfs := newTestZipFS(t)
+
+	r := gin.New()
+	StaticFS(r.Group("/"), "/", "", zfs, "", HashETag())
+
+	rf := gin.New()
+	StaticFSFile(rf.Group("/file"), "/logo.png", "img/logo.png", zfs, "")
+
+	w := testGetCode(t, r, "/js/app.js", http.StatusOK)
+	if a := w.Body.String(); a != strings.Repeat("console.log(1);\n", 100) {
+		t.Errorf("body = %q", a)
+	}
+	if a := w.Header().Get("Last-Modified"); a != zipModTime.Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q", a)
+	}
+	etag := w.Header().Get("ETag")
+
+	// gzip
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/js/app.js", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("gzip = %d %v", w.Code, w.Header())
+	}
+	if a := w.Header().Get("Content-Type"); !strings.HasPrefix(a, "text/javascript") && !strings.HasPrefix(a, "application/javascript") {
+		t.Errorf("Content-Type = %q", a)
+	}
+	if a := w.Header().Get("ETag"); a == "" || a == etag {
+		t.Errorf("gzip ETag = %q, identity ETag = %q", a, etag)
+	}
+	if a := w.Body.Len(); a >= 1600 {
+		t.Errorf("gzip body size = %d", a)
+	}
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != strings.Repeat("console.log(1);\n", 100) {
+		t.Errorf("gunzip body = %q", bs)
+	}
+
+	// stored entry is not gzip encoded
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/file/logo.png", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Range", "bytes=2-4")
+	rf.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Header().Get("Content-Encoding") != "" || w.Body.String() != "234" {
+		t.Errorf("Range = %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+
+	// range of deflated entry
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/css/app.css", nil)
+	req.Header.Set("Range", "bytes=7-11")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "color" {
+		t.Errorf("Range = %d %q", w.Code, w.Body.String())
+	}
+
+	w = testGetCode(t, r, "/", http.StatusOK)
+	if a := w.Body.String(); !strings.HasPrefix(a, "<html>index") {
+		t.Errorf("index = %q", a)
+	}
+	w = testGetCode(t, r, "/css/", http.StatusOK)
+	if a := w.Body.String(); !strings.Contains(a, "app.css") {
+		t.Errorf("listing = %q", a)
+	}
+	testGetCode(t, r, "/none.txt", http.StatusNotFound)
+}
+
+func TestOpenZipFS(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "static.zip")
+	if err := os.WriteFile(name, zipBytes(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	zfs, err := OpenZipFS(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zfs.Close()
+
+	r := gin.New()
+	StaticFS(r.Group("/"), "/", "", zfs, "")
+	testGetCode(t, r, "/img/logo.png", http.StatusOK)
+
+	if _, err := OpenZipFS(filepath.Join(t.TempDir(), "none.zip")); err == nil {
+		t.Error("OpenZipFS(none.zip) error expected")
+	}
+}
@@ -58,7 +58,7 @@ func (g *gzipWriter) checkHeader() {
 	}
 
 	h := g.ResponseWriter.Header()
-	if str.ContainsFold(h.Get("Content-Encoding"), "gzip") {
+	if h.Get("Content-Encoding") != "" {
 		g.state = stateSkip
 		return
 	}
@@ -131,6 +131,26 @@ func TestGzipIngoreContentType(t *testing.T) {
 	assertGzipIgnore(t, rr, body)
 }
 
+func TestGzipIgnoreContentEncoding(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/app.js", nil)
+	req.Header.Add("Accept-Encoding", "br, gzip")
+
+	body := strings.Repeat("This is a brotli body!\n", 1000)
+	router := gin.New()
+	router.Use(Default().Handler())
+	router.GET("/app.js", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.String(200, body)
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assertHeader(t, rr, http.StatusOK, "br", "", "")
+	if rr.Body.String() != body {
+		t.Errorf(`Body = %v, want %v`, rr.Body.String(), body)
+	}
+}
+
 func TestGzipIgnorePathPrefix(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/api/books", nil)
 	req.Header.Add("Accept-Encoding", "gzip")